@@ -6,7 +6,6 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/binary"
-	"errors"
 	"time"
 
 	"golang.org/x/crypto/argon2"
@@ -19,7 +18,7 @@ var DeadlineExceeded error = deadlineExceededError{}
 type deadlineExceededError struct{}
 
 func (deadlineExceededError) Error() string {
-	return "srp: deadline exceeded calculating proof-of-work challenge"
+	return "pm-srp: deadline exceeded calculating proof-of-work challenge"
 }
 func (deadlineExceededError) Timeout() bool   { return true }
 func (deadlineExceededError) Temporary() bool { return true }
@@ -41,7 +40,7 @@ func ECDLPChallenge(b64Challenge string, deadlineUnixMilli int64) (b64Solution s
 	}
 
 	if len(challenge) != 2*ecdlpPRFKeySize+sha256.Size {
-		return "", errors.New("srp: invalid ECDLP challenge length")
+		return "", ErrInvalidECDLPChallenge
 	}
 
 	var i uint64
@@ -88,7 +87,7 @@ func Argon2PreimageChallenge(b64Challenge string, deadlineUnixMilli int64) (b64S
 
 	// Argon2 challenges consist of 3 PRF keys, the hash output, and 4 32-bit argon2 parameters
 	if len(challenge) != 3*argon2PRFKeySize+sha256.Size+4*4 {
-		return "", errors.New("srp: invalid Argon2 preimage challenge length")
+		return "", ErrInvalidArgon2Challenge
 	}
 	prfKeys := challenge[:3*argon2PRFKeySize]
 	goal := challenge[3*argon2PRFKeySize:][:sha256.Size]
@@ -1,6 +1,7 @@
 package srp
 
 import (
+	"errors"
 	"strings"
 	"testing"
 	"time"
@@ -49,3 +50,12 @@ func TestArgon2PreimageChallengeTimeout(t *testing.T) {
 		t.Fatal("Expected timeout in Argon2 preimage challenge")
 	}
 }
+
+func TestChallengeLength(t *testing.T) {
+	if _, err := ECDLPChallenge(strings.Repeat("A", 12), -1); !errors.Is(err, ErrInvalidECDLPChallenge) {
+		t.Fatal("Expected the ErrInvalidECDLPChallenge but have ", err)
+	}
+	if _, err := Argon2PreimageChallenge(strings.Repeat("A", 12), -1); !errors.Is(err, ErrInvalidArgon2Challenge) {
+		t.Fatal("Expected the ErrInvalidArgon2Challenge but have ", err)
+	}
+}
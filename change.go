@@ -0,0 +1,94 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+// PasswordChange holds what the client sends to change its password: the SRP
+// proofs for the current password and the verifier bundle of the new one.
+type PasswordChange struct {
+	Proofs *Proofs
+	Bundle *VerifierBundle
+}
+
+// PreparePasswordChange computes the proofs of the current password answering
+// the auth info, and the verifier bundle of the new password for the new signed
+// modulus. Both passwords are wiped when done. The options apply to both the
+// proofs and the verifier bundle.
+func PreparePasswordChange(info *AuthInfo, username string, oldPassword, newPassword []byte, newSignedModulus string, opts ...Option) (*PasswordChange, error) {
+	defer clear(oldPassword)
+	defer clear(newPassword)
+
+	auth, err := NewAuthFromInfo(info, username, oldPassword, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(auth.HashedPassword)
+
+	proofs, err := auth.GenerateProofs(len(auth.Modulus) * 8)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle, err := NewVerifierBundle(newPassword, newSignedModulus, append([]Option{WithUsername(username)}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PasswordChange{
+		Proofs: proofs,
+		Bundle: bundle,
+	}, nil
+}
+
+// VerifyPasswordChange checks that the new verifier bundle is well formed for
+// the group of the new modulus, and only then verifies the client proofs for
+// the current password. It returns the server proof if both succeed, the
+// exchange is not concluded otherwise. An invalid bundle leaves the server
+// untouched, and is neither reported to the observer nor to the limiter.
+func (s *Server) VerifyPasswordChange(clientEphemeralBytes, clientProofBytes []byte, bundle *VerifierBundle, group *Group) (serverProof []byte, err error) {
+	if err := checkVerifierBundle(bundle, group); err != nil {
+		return nil, err
+	}
+	return s.VerifyProofs(clientEphemeralBytes, clientProofBytes)
+}
+
+// checkVerifierBundle validates the version, salt and verifier of the bundle.
+func checkVerifierBundle(bundle *VerifierBundle, group *Group) error {
+	if bundle == nil || group == nil {
+		return ErrInvalidVerifierBundle
+	}
+
+	switch {
+	case bundle.Version < 0 || bundle.Version > 4:
+		return ErrUnsupportedVersion
+	case bundle.Version >= 3 && len(bundle.Salt) != SaltLength:
+		return ErrInvalidVerifierBundle
+	case bundle.Version < 3 && len(bundle.Salt) != 0:
+		return ErrInvalidVerifierBundle
+	case bundle.ModulusID != group.ID():
+		return ErrInvalidVerifierBundle
+	case group.checkVerifier(bundle.Verifier) != nil:
+		return ErrInvalidVerifierBundle
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPasswordChange(t *testing.T) {
+	group, err := NewGroupFromSigned(testModulusClearSign, 2048)
+	if err != nil {
+		t.Fatal("Expected no error while creating group, have ", err)
+	}
+	oldBundle, err := NewVerifierBundle([]byte("old password"), testModulusClearSign)
+	if err != nil {
+		t.Fatal("Expected no error while generating verifier bundle, have ", err)
+	}
+
+	newChallenge := func() (*Server, *AuthInfo) {
+		server, err := NewServerWithOptions(group.Modulus(), oldBundle.Verifier, 2048, WithGroup(group))
+		if err != nil {
+			t.Fatal("Expected no error while creating server, have ", err)
+		}
+		challenge, err := server.GenerateChallenge()
+		if err != nil {
+			t.Fatal("Expected no error while generating challenge, have ", err)
+		}
+		return server, &AuthInfo{
+			Version:         oldBundle.Version,
+			Modulus:         testModulusClearSign,
+			ServerEphemeral: base64.StdEncoding.EncodeToString(challenge),
+			Salt:            base64.StdEncoding.EncodeToString(oldBundle.Salt),
+		}
+	}
+
+	server, info := newChallenge()
+	oldPassword, newPassword := []byte("old password"), []byte("new password")
+	change, err := PreparePasswordChange(info, "Test", oldPassword, newPassword, testModulusClearSign)
+	if err != nil {
+		t.Fatal("Expected no error while preparing password change, have ", err)
+	}
+	if !bytes.Equal(oldPassword, make([]byte, len(oldPassword))) || !bytes.Equal(newPassword, make([]byte, len(newPassword))) {
+		t.Fatal("Expected the passwords to be wiped")
+	}
+
+	serverProof, err := server.VerifyPasswordChange(change.Proofs.ClientEphemeral, change.Proofs.ClientProof, change.Bundle, group)
+	if err != nil {
+		t.Fatal("Expected no error while verifying password change, have ", err)
+	}
+	if err := change.Proofs.VerifyServerProof(serverProof); err != nil {
+		t.Fatal("Expected no error while verifying server proof, have ", err)
+	}
+
+	// The new verifier must be usable for the next login
+	newServer, err := NewServerWithOptions(group.Modulus(), change.Bundle.Verifier, 2048, WithGroup(group))
+	if err != nil {
+		t.Fatal("Expected no error while creating server, have ", err)
+	}
+	challenge, err := newServer.GenerateChallenge()
+	if err != nil {
+		t.Fatal("Expected no error while generating challenge, have ", err)
+	}
+	auth, err := NewAuth(change.Bundle.Version, "Test", []byte("new password"), base64.StdEncoding.EncodeToString(change.Bundle.Salt), testModulusClearSign, base64.StdEncoding.EncodeToString(challenge))
+	if err != nil {
+		t.Fatal("Expected no error while creating auth, have ", err)
+	}
+	proofs, err := auth.GenerateProofs(2048)
+	if err != nil {
+		t.Fatal("Expected no error while generating client proofs, have ", err)
+	}
+	if _, err := newServer.VerifyProofs(proofs.ClientEphemeral, proofs.ClientProof); err != nil {
+		t.Fatal("Expected no error while verifying client proofs with the new verifier, have ", err)
+	}
+}
+
+func TestPasswordChangeRejected(t *testing.T) {
+	group, err := NewGroupFromSigned(testModulusClearSign, 2048)
+	if err != nil {
+		t.Fatal("Expected no error while creating group, have ", err)
+	}
+	oldBundle, err := NewVerifierBundle([]byte("old password"), testModulusClearSign)
+	if err != nil {
+		t.Fatal("Expected no error while generating verifier bundle, have ", err)
+	}
+
+	observer := &recordingObserver{}
+	limiter := NewMemoryLimiter(10, time.Hour, time.Nanosecond, time.Nanosecond)
+	prepare := func(oldPassword string) (*Server, *PasswordChange) {
+		server, err := NewServerWithOptions(group.Modulus(), oldBundle.Verifier, 2048, WithGroup(group), WithUsername("Test"), WithObserver(observer), WithLimiter(limiter))
+		if err != nil {
+			t.Fatal("Expected no error while creating server, have ", err)
+		}
+		challenge, err := server.GenerateChallenge()
+		if err != nil {
+			t.Fatal("Expected no error while generating challenge, have ", err)
+		}
+		info := &AuthInfo{
+			Version:         oldBundle.Version,
+			Modulus:         testModulusClearSign,
+			ServerEphemeral: base64.StdEncoding.EncodeToString(challenge),
+			Salt:            base64.StdEncoding.EncodeToString(oldBundle.Salt),
+		}
+		change, err := PreparePasswordChange(info, "Test", []byte(oldPassword), []byte("new password"), testModulusClearSign)
+		if err != nil {
+			t.Fatal("Expected no error while preparing password change, have ", err)
+		}
+		return server, change
+	}
+
+	server, change := prepare("wrong password")
+	if _, err := server.VerifyPasswordChange(change.Proofs.ClientEphemeral, change.Proofs.ClientProof, change.Bundle, group); !errors.Is(err, ErrInvalidClientProof) {
+		t.Fatal("Expected the ErrInvalidClientProof but have ", err)
+	}
+
+	server, change = prepare("old password")
+	observer.events = nil
+	change.Bundle.Salt = change.Bundle.Salt[:4]
+	if _, err := server.VerifyPasswordChange(change.Proofs.ClientEphemeral, change.Proofs.ClientProof, change.Bundle, group); !errors.Is(err, ErrInvalidVerifierBundle) {
+		t.Fatal("Expected the ErrInvalidVerifierBundle but have ", err)
+	}
+	if server.IsCompleted() {
+		t.Fatal("Expected the exchange not to be completed with an invalid verifier bundle")
+	}
+	if len(observer.events) != 0 {
+		t.Fatal("Expected an invalid verifier bundle not to be reported as a verification but have ", observer.events)
+	}
+	if failures := limiter.keys["Test"].failures; failures != 1 {
+		t.Fatal("Expected only the wrong password to be recorded by the limiter but have ", failures)
+	}
+
+	server, change = prepare("old password")
+	change.Bundle.Verifier = make([]byte, 256)
+	if _, err := server.VerifyPasswordChange(change.Proofs.ClientEphemeral, change.Proofs.ClientProof, change.Bundle, group); !errors.Is(err, ErrInvalidVerifierBundle) {
+		t.Fatal("Expected the ErrInvalidVerifierBundle but have ", err)
+	}
+}
@@ -0,0 +1,94 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+// Client stores the internal state of the client side of an SRP exchange.
+// It is the counterpart of Server: a client is created from the auth info,
+// processes a single server challenge, and concludes the exchange once the
+// server proof has been verified.
+type Client struct {
+	key       *PasswordKey
+	proofs    *Proofs
+	bitLength int
+}
+
+// NewClient creates a new client instance. Salt is in base64 format. Modulus is
+// base64 with signature attached. The signature is verified against server key.
+// The version controls password hash algorithm. A bitLength of 0 infers the
+// size from the modulus.
+func NewClient(version int, username string, password []byte, b64salt, signedModulus string, bitLength int) (*Client, error) {
+	return NewClientWithOptions(version, username, password, b64salt, signedModulus, bitLength)
+}
+
+// NewClientWithOptions is NewClient with options, such as WithRandReader or WithGroup.
+func NewClientWithOptions(version int, username string, password []byte, b64salt, signedModulus string, bitLength int, opts ...Option) (*Client, error) {
+	key, err := NewPasswordKey(version, username, password, b64salt, signedModulus, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		key:       key,
+		bitLength: bitLength,
+	}, nil
+}
+
+// ProcessChallenge is the first step for the client, and computes the client
+// ephemeral and proof answering the raw server ephemeral. It can be called only once.
+func (c *Client) ProcessChallenge(serverEphemeral []byte) (clientEphemeral, clientProof []byte, err error) {
+	if c.proofs != nil {
+		return nil, nil, ErrChallengeAlreadyProcessed
+	}
+
+	proofs, err := c.key.GenerateProofs(serverEphemeral, c.bitLength)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.proofs = proofs
+
+	return proofs.ClientEphemeral, proofs.ClientProof, nil
+}
+
+// VerifyServer verifies the raw server proof. It concludes the exchange in valid
+// state if successful. It must be called after ProcessChallenge and only once.
+func (c *Client) VerifyServer(serverProof []byte) error {
+	if c.proofs == nil {
+		return ErrChallengeNotProcessed
+	}
+	if c.proofs.IsCompleted() {
+		return ErrServerAlreadyVerified
+	}
+	return c.proofs.VerifyServerProof(serverProof)
+}
+
+// IsCompleted returns true if the exchange has been concluded in valid state.
+func (c *Client) IsCompleted() bool {
+	return c.proofs != nil && c.proofs.IsCompleted()
+}
+
+// SharedSession returns the shared secret as byte if the session has concluded in valid state.
+func (c *Client) SharedSession() ([]byte, error) {
+	if c.proofs == nil {
+		return nil, ErrNotCompleted
+	}
+	return c.proofs.GetSharedSession()
+}
@@ -0,0 +1,105 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestClientServerExchange(t *testing.T) {
+	var bits = 2048
+	var password = []byte("Password\nabc!!~~ä\r\n")
+
+	rawSalt, err := RandomBytes(10)
+	if err != nil {
+		t.Fatal("Expected no error while generating raw salt, have ", err)
+	}
+	verifierAuth, err := NewAuthForVerifier(password, testModulusClearSign, rawSalt)
+	if err != nil {
+		t.Fatal("Expected no error while creating auth for verifier, have ", err)
+	}
+	verifier, err := verifierAuth.GenerateVerifier(bits)
+	if err != nil {
+		t.Fatal("Expected no error while generating verifier, have ", err)
+	}
+
+	server, err := NewServerFromSigned(testModulusClearSign, verifier, bits)
+	if err != nil {
+		t.Fatal("Expected no error while creating server, have ", err)
+	}
+	client, err := NewClient(4, "Test", password, base64.StdEncoding.EncodeToString(rawSalt), testModulusClearSign, bits)
+	if err != nil {
+		t.Fatal("Expected no error while creating client, have ", err)
+	}
+
+	if err := client.VerifyServer(make([]byte, 256)); !errors.Is(err, ErrChallengeNotProcessed) {
+		t.Fatal("Expected the ErrChallengeNotProcessed but have ", err)
+	}
+	if _, err := client.SharedSession(); !errors.Is(err, ErrNotCompleted) {
+		t.Fatal("Expected the ErrNotCompleted but have ", err)
+	}
+
+	challenge, err := server.GenerateChallenge()
+	if err != nil {
+		t.Fatal("Expected no error while generating challenge, have ", err)
+	}
+	clientEphemeral, clientProof, err := client.ProcessChallenge(challenge)
+	if err != nil {
+		t.Fatal("Expected no error while processing challenge, have ", err)
+	}
+	if _, _, err := client.ProcessChallenge(challenge); !errors.Is(err, ErrChallengeAlreadyProcessed) {
+		t.Fatal("Expected the ErrChallengeAlreadyProcessed but have ", err)
+	}
+	if _, err := client.SharedSession(); !errors.Is(err, ErrNotCompleted) {
+		t.Fatal("Expected the ErrNotCompleted but have ", err)
+	}
+
+	serverProof, err := server.VerifyProofs(clientEphemeral, clientProof)
+	if err != nil {
+		t.Fatal("Expected no error while verifying client proofs, have ", err)
+	}
+	if err := client.VerifyServer(serverProof); err != nil {
+		t.Fatal("Expected no error while verifying server proof, have ", err)
+	}
+	if err := client.VerifyServer(serverProof); !errors.Is(err, ErrServerAlreadyVerified) {
+		t.Fatal("Expected the ErrServerAlreadyVerified but have ", err)
+	}
+	if !client.IsCompleted() {
+		t.Fatal("Expected client side of the SRP exchange to be completed")
+	}
+
+	clientSharedSession, err := client.SharedSession()
+	if err != nil {
+		t.Fatal("Expected no error while getting client shared session, have ", err)
+	}
+	serverSharedSession, err := server.GetSharedSession()
+	if err != nil {
+		t.Fatal("Expected no error while getting server shared session, have ", err)
+	}
+	if !bytes.Equal(clientSharedSession, serverSharedSession) {
+		t.Fatal("Expected client and server to share the same session")
+	}
+}
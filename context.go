@@ -0,0 +1,74 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+import (
+	"context"
+)
+
+// runWithContext runs fn in its own goroutine and returns as soon as fn is done
+// or ctx is cancelled, whichever comes first. The computation itself cannot be
+// interrupted: on cancellation it completes in the background and its result is
+// dropped, so fn must only use values that the caller cannot change. A context
+// that can never be cancelled runs fn on the calling goroutine.
+func runWithContext(ctx context.Context, fn func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if ctx.Done() == nil {
+		return fn()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- fn()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// NewAuthContext is like NewAuth but checks ctx between the signature check,
+// the modulus validation and the password hash, and returns ctx.Err() as soon as
+// ctx is cancelled during the hash. The hash itself cannot be interrupted: it
+// completes in the background on a copy of the password, which is wiped once
+// done, and its result is dropped.
+func NewAuthContext(ctx context.Context, version int, username string, password []byte, b64salt, signedModulus, serverEphemeral string, opts ...Option) (*Auth, error) {
+	key, err := newPasswordKey(ctx, version, username, password, b64salt, signedModulus, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return key.NewAuth(serverEphemeral)
+}
+
+// GenerateProofsContext is like GenerateProofs but checks ctx before the
+// modulus validation and each exponentiation, and returns ctx.Err() once it is
+// cancelled. It runs on the calling goroutine, no work goes on after it
+// returned.
+func (s *Auth) GenerateProofsContext(ctx context.Context, bitLength int) (*Proofs, error) {
+	return s.generateProofs(ctx, bitLength)
+}
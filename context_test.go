@@ -0,0 +1,111 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNewAuthContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewAuthContext(ctx, 4, "jakubqa", []byte("abc123"), "yKlc5/CvObfoiw==", testModulusClearSign, testServerEphemeral); err != context.Canceled {
+		t.Fatal("Expected context.Canceled but have ", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if _, err := NewAuthContext(ctx, 4, "jakubqa", []byte("abc123"), "yKlc5/CvObfoiw==", testModulusClearSign, testServerEphemeral); err != context.DeadlineExceeded {
+		t.Fatal("Expected context.DeadlineExceeded but have ", err)
+	}
+
+	auth, err := NewAuthContext(context.Background(), 4, "jakubqa", []byte("abc123"), "yKlc5/CvObfoiw==", testModulusClearSign, testServerEphemeral)
+	if err != nil {
+		t.Fatal("Expected no error but have ", err)
+	}
+	if len(auth.HashedPassword) == 0 {
+		t.Fatal("Expected the password to be hashed")
+	}
+}
+
+func TestGenerateProofsContext(t *testing.T) {
+	auth, err := NewAuth(4, "jakubqa", []byte("abc123"), "yKlc5/CvObfoiw==", testModulusClearSign, testServerEphemeral)
+	if err != nil {
+		t.Fatal("Expected no error but have ", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := auth.GenerateProofsContext(ctx, 2048); err != context.Canceled {
+		t.Fatal("Expected context.Canceled but have ", err)
+	}
+
+	proofs, err := auth.GenerateProofsContext(context.Background(), 2048)
+	if err != nil {
+		t.Fatal("Expected no error but have ", err)
+	}
+	if len(proofs.ClientProof) == 0 || len(proofs.ExpectedServerProof) == 0 {
+		t.Fatal("Expected proofs to be generated")
+	}
+}
+
+// countdownContext is cancelled after its Err method was called a number of
+// times, to cancel a computation between two given stages.
+type countdownContext struct {
+	context.Context
+	calls int
+}
+
+func (c *countdownContext) Err() error {
+	if c.calls == 0 {
+		return context.Canceled
+	}
+	c.calls--
+	return nil
+}
+
+func TestContextStages(t *testing.T) {
+	auth, err := NewAuth(4, "jakubqa", []byte("abc123"), "yKlc5/CvObfoiw==", testModulusClearSign, testServerEphemeral)
+	if err != nil {
+		t.Fatal("Expected no error but have ", err)
+	}
+	for calls := 0; calls < 4; calls++ {
+		if _, err := auth.GenerateProofsContext(&countdownContext{context.Background(), calls}, 2048); err != context.Canceled {
+			t.Fatal("Expected context.Canceled between the stages of the proofs but have ", err)
+		}
+	}
+	if _, err := auth.GenerateProofsContext(&countdownContext{context.Background(), 4}, 2048); err != nil {
+		t.Fatal("Expected no error but have ", err)
+	}
+
+	for calls := 0; calls < 3; calls++ {
+		if _, err := NewAuthContext(&countdownContext{context.Background(), calls}, 4, "jakubqa", []byte("abc123"), "yKlc5/CvObfoiw==", testModulusClearSign, testServerEphemeral); err != context.Canceled {
+			t.Fatal("Expected context.Canceled between the stages of the password hash but have ", err)
+		}
+	}
+	if _, err := NewAuthContext(&countdownContext{context.Background(), 3}, 4, "jakubqa", []byte("abc123"), "yKlc5/CvObfoiw==", testModulusClearSign, testServerEphemeral); err != nil {
+		t.Fatal("Expected no error but have ", err)
+	}
+}
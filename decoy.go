@@ -0,0 +1,64 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"math/big"
+)
+
+// DecoyRecord derives a fake verifier bundle for a username without account, so
+// that unknown usernames get a challenge like real ones and only fail at
+// VerifyProofs. The salt and verifier are derived from an HMAC-SHA512 of the
+// username keyed by serverKey, which must be secret and stable across restarts
+// and nodes, so the same username always gets the same record. Decoys use the
+// DefaultVersion, like new accounts.
+//
+// The verifier is the HMAC expanded and reduced into [2, N-1], without any
+// modular exponentiation, so that deriving a decoy costs about as little as
+// looking up an account.
+func DecoyRecord(username string, serverKey []byte, group *Group) (*VerifierBundle, error) {
+	if len(serverKey) == 0 {
+		return nil, ErrInvalidDecoyKey
+	}
+
+	salt := decoyHMAC(serverKey, "salt", username)[:SaltLength]
+	// The extra 64 bytes make the bias of the reduction negligible.
+	expanded := SHA512Expander.Expand(decoyHMAC(serverKey, "verifier", username), group.bitLength/8+64)
+	verifier := new(big.Int).Mod(group.toInt(expanded), new(big.Int).Sub(group.modulusInt, big.NewInt(2)))
+	verifier.Add(verifier, big.NewInt(2))
+
+	return &VerifierBundle{
+		Version:   DefaultVersion,
+		ModulusID: group.ID(),
+		Salt:      salt,
+		Verifier:  group.fromInt(verifier),
+	}, nil
+}
+
+func decoyHMAC(serverKey []byte, label, username string) []byte {
+	mac := hmac.New(sha512.New, serverKey)
+	_, _ = mac.Write([]byte("go-srp decoy " + label + "\x00" + username))
+	return mac.Sum(nil)
+}
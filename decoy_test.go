@@ -0,0 +1,97 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestDecoyRecord(t *testing.T) {
+	group, err := NewGroupFromSigned(testModulusClearSign, 0)
+	if err != nil {
+		t.Fatal("Expected no error while creating group, have ", err)
+	}
+	serverKey := []byte("decoy server key")
+
+	decoy, err := DecoyRecord("unknown", serverKey, group)
+	if err != nil {
+		t.Fatal("Expected no error while deriving decoy, have ", err)
+	}
+	if decoy.Version != DefaultVersion || decoy.ModulusID != group.ID() {
+		t.Fatal("Expected the decoy to use the default version and the group modulus")
+	}
+	if len(decoy.Salt) != SaltLength || len(decoy.Verifier) != group.BitLength()/8 {
+		t.Fatal("Expected the decoy salt and verifier to have the size of real ones")
+	}
+	if err := checkVerifierBundle(decoy, group); err != nil {
+		t.Fatal("Expected the decoy to be a valid verifier bundle but have ", err)
+	}
+
+	again, err := DecoyRecord("unknown", serverKey, group)
+	if err != nil {
+		t.Fatal("Expected no error while deriving decoy, have ", err)
+	}
+	if !bytes.Equal(decoy.Salt, again.Salt) || !bytes.Equal(decoy.Verifier, again.Verifier) {
+		t.Fatal("Expected the decoy of a username to be stable")
+	}
+	other, err := DecoyRecord("other", serverKey, group)
+	if err != nil {
+		t.Fatal("Expected no error while deriving decoy, have ", err)
+	}
+	if bytes.Equal(decoy.Salt, other.Salt) || bytes.Equal(decoy.Verifier, other.Verifier) {
+		t.Fatal("Expected different usernames to get different decoys")
+	}
+	otherKey, err := DecoyRecord("unknown", []byte("other server key"), group)
+	if err != nil {
+		t.Fatal("Expected no error while deriving decoy, have ", err)
+	}
+	if bytes.Equal(decoy.Salt, otherKey.Salt) || bytes.Equal(decoy.Verifier, otherKey.Verifier) {
+		t.Fatal("Expected different server keys to get different decoys")
+	}
+
+	if _, err := DecoyRecord("unknown", nil, group); !errors.Is(err, ErrInvalidDecoyKey) {
+		t.Fatal("Expected the ErrInvalidDecoyKey but have ", err)
+	}
+
+	key, err := NewPasswordKeyForGroup(group, decoy.Version, "unknown", []byte("abc123"), decoy.Salt)
+	if err != nil {
+		t.Fatal("Expected no error while hashing password, have ", err)
+	}
+	server, err := NewServerWithOptions(group.Modulus(), decoy.Verifier, 0, WithGroup(group))
+	if err != nil {
+		t.Fatal("Expected no error while creating server, have ", err)
+	}
+	serverEphemeral, err := server.GenerateChallenge()
+	if err != nil {
+		t.Fatal("Expected no error while generating challenge, have ", err)
+	}
+	proofs, err := key.GenerateProofs(serverEphemeral, 0)
+	if err != nil {
+		t.Fatal("Expected no error while generating proofs, have ", err)
+	}
+	if _, err := server.VerifyProofs(proofs.ClientEphemeral, proofs.ClientProof); !errors.Is(err, ErrInvalidClientProof) {
+		t.Fatal("Expected the decoy to fail at VerifyProofs with ErrInvalidClientProof but have ", err)
+	}
+}
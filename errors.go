@@ -0,0 +1,152 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+import (
+	"errors"
+)
+
+var (
+	// ErrDataAfterModulus found extra data after decode the modulus
+	ErrDataAfterModulus = errors.New("pm-srp: extra data after modulus")
+
+	// ErrInvalidSignature invalid modulus signature
+	ErrInvalidSignature = errors.New("pm-srp: invalid modulus signature")
+
+	// ErrInvalidModulusKey the embedded modulus verification key cannot be read
+	ErrInvalidModulusKey = errors.New("pm-srp: can not read modulus pubkey")
+
+	// ErrUnsupportedGenerator the SRP generator is not 2
+	ErrUnsupportedGenerator = errors.New("pm-srp: SRP generator must always be 2")
+
+	// ErrUnsupportedSuite the suite cannot be used with the requested group
+	ErrUnsupportedSuite = errors.New("pm-srp: unsupported SRP suite")
+
+	// ErrModulusSize the SRP modulus does not have the expected bit length
+	ErrModulusSize = errors.New("pm-srp: SRP modulus has incorrect size")
+
+	// ErrModulusNot3Mod8 the SRP modulus is not 3 mod 8, so 2 does not generate the whole group
+	ErrModulusNot3Mod8 = errors.New("pm-srp: SRP modulus is not 3 mod 8")
+
+	// ErrModulusNotSafePrime (N-1)/2 is not prime
+	ErrModulusNotSafePrime = errors.New("pm-srp: SRP modulus is not a safe prime")
+
+	// ErrModulusNotPrime the SRP modulus is not prime
+	ErrModulusNotPrime = errors.New("pm-srp: SRP modulus is not prime")
+
+	// ErrMultiplierOutOfBounds the SRP multiplier is not in ]1, N-1[
+	ErrMultiplierOutOfBounds = errors.New("pm-srp: SRP multiplier is out of bounds")
+
+	// ErrEphemeralOutOfBounds the ephemeral received from the other party is not in ]1, N-1[
+	ErrEphemeralOutOfBounds = errors.New("pm-srp: SRP ephemeral is out of bounds")
+
+	// ErrInvalidEphemeral the ephemerals hash to a zero scrambling parameter
+	ErrInvalidEphemeral = errors.New("pm-srp: SRP ephemeral is invalid")
+
+	// ErrVerifierOutOfBounds the verifier is not in [2, N-1]
+	ErrVerifierOutOfBounds = errors.New("pm-srp: SRP verifier is out of bounds")
+
+	// ErrInvalidLength a byte input does not have the size expected for the group or auth version
+	ErrInvalidLength = errors.New("pm-srp: invalid input length")
+
+	// ErrInvalidSecret the provided server secret is too small or too large
+	ErrInvalidSecret = errors.New("pm-srp: invalid secret")
+
+	// ErrInvalidClientProof the client proof does not match the expected one
+	ErrInvalidClientProof = errors.New("pm-srp: invalid SRP client proof")
+
+	// ErrInvalidServerProof the server proof does not match the expected one
+	ErrInvalidServerProof = errors.New("pm-srp: invalid SRP server proof")
+
+	// ErrChallengeNotGenerated VerifyProofs was called before GenerateChallenge
+	ErrChallengeNotGenerated = errors.New("pm-srp: SRP server ephemeral is not generated")
+
+	// ErrChallengeNotProcessed the client has not processed a challenge yet
+	ErrChallengeNotProcessed = errors.New("pm-srp: SRP challenge is not processed")
+
+	// ErrChallengeAlreadyProcessed the client already processed a challenge
+	ErrChallengeAlreadyProcessed = errors.New("pm-srp: SRP challenge already processed")
+
+	// ErrServerAlreadyVerified the client already verified the server proof
+	ErrServerAlreadyVerified = errors.New("pm-srp: SRP server proof already verified")
+
+	// ErrSharedSessionUnavailable the shared session was wiped after a failed verification
+	ErrSharedSessionUnavailable = errors.New("pm-srp: SRP shared session is not available")
+
+	// ErrChallengeAlreadyUsed the server already verified client proofs for its challenge
+	ErrChallengeAlreadyUsed = errors.New("pm-srp: SRP challenge already used")
+
+	// ErrChallengeExpired the client proofs were received after the challenge lifetime
+	ErrChallengeExpired = errors.New("pm-srp: SRP challenge expired")
+
+	// ErrNotCompleted the exchange has not concluded in valid state
+	ErrNotCompleted = errors.New("pm-srp: SRP is not completed")
+
+	// ErrUnsupportedVersion the auth version is not supported
+	ErrUnsupportedVersion = errors.New("pm-srp: unsupported auth version")
+
+	// ErrUsernameRequired the legacy auth versions hash the username, which is missing
+	ErrUsernameRequired = errors.New("pm-srp: username is required by legacy auth versions")
+
+	// ErrInvalidVerifierBundle the verifier bundle does not match the new modulus or is malformed
+	ErrInvalidVerifierBundle = errors.New("pm-srp: invalid verifier bundle")
+
+	// ErrInvalidECDLPChallenge the ECDLP proof-of-work challenge does not have the expected length
+	ErrInvalidECDLPChallenge = errors.New("pm-srp: invalid ECDLP challenge length")
+
+	// ErrInvalidArgon2Challenge the Argon2 preimage proof-of-work challenge does not have the expected length
+	ErrInvalidArgon2Challenge = errors.New("pm-srp: invalid Argon2 preimage challenge length")
+
+	// ErrInvalidSessionKeyLabel the session key label is empty
+	ErrInvalidSessionKeyLabel = errors.New("pm-srp: empty session key label")
+
+	// ErrInvalidSessionKeyLength the session key length is out of range
+	ErrInvalidSessionKeyLength = errors.New("pm-srp: invalid session key length")
+
+	// ErrInvalidServerState the serialized server state is malformed or cannot be opened
+	ErrInvalidServerState = errors.New("pm-srp: invalid server state")
+
+	// ErrUnsupportedServerState the serialized server state has an unknown format version
+	ErrUnsupportedServerState = errors.New("pm-srp: unsupported server state version")
+
+	// ErrSessionNotFound the SRP session does not exist, has expired or was already used
+	ErrSessionNotFound = errors.New("pm-srp: SRP session not found")
+
+	// ErrInvalidDecoyKey the server key of the decoy records is empty
+	ErrInvalidDecoyKey = errors.New("pm-srp: empty decoy key")
+
+	// ErrInvalidMasterKey the master key of the derived server secrets is empty
+	ErrInvalidMasterKey = errors.New("pm-srp: empty master key")
+
+	// ErrChallengeLifetimeRequired master key sessions need a challenge lifetime
+	ErrChallengeLifetimeRequired = errors.New("pm-srp: challenge lifetime required")
+
+	// ErrSessionLedgerRequired master key sessions need a ledger of the consumed session IDs
+	ErrSessionLedgerRequired = errors.New("pm-srp: session ledger required")
+
+	// ErrRateLimited the limiter refuses a new challenge for the username
+	ErrRateLimited = errors.New("pm-srp: too many SRP attempts")
+
+	// ErrLimiterKeyRequired the server has a limiter but neither a username nor a limiter key
+	ErrLimiterKeyRequired = errors.New("pm-srp: limiter key required")
+)
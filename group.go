@@ -0,0 +1,181 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"math/big"
+
+	"github.com/cronokirby/saferith"
+)
+
+// Group is an SRP group built from a modulus. The modulus is validated once on
+// creation, and the values derived from it, such as the multiplier, are cached.
+// A group is immutable and can be shared by any number of Auth and Server
+// instances through the WithGroup option.
+type Group struct {
+	suite                          *Suite
+	modulusBytes                   []byte
+	bitLength                      int
+	generator                      *big.Int
+	generatorNat                   *saferith.Nat
+	modulusInt, modulusMinusOneInt *big.Int
+	modulusMinusOneNat             *saferith.Nat
+	modulus, modulusMinusOne       *saferith.Modulus
+	multiplier                     *saferith.Nat
+}
+
+// NewGroup validates the raw modulus and creates a new group from it, for the
+// Proton suite with generator 2. The bit length is inferred from the modulus
+// if bitLength is 0, and must match it otherwise. Hashes are expanded to the size
+// of the modulus, so 3072 and 4096 bits moduli are supported as well as 2048.
+func NewGroup(modulusBytes []byte, bitLength int) (*Group, error) {
+	return NewGroupForSuite(SuiteProton, modulusBytes, bitLength)
+}
+
+// NewGroupForSuite is NewGroup for a variant of the Proton suite returned by
+// NewSuite. The groups of the RFC 5054 suites are created with RFC5054Group.
+func NewGroupForSuite(suite *Suite, modulusBytes []byte, bitLength int) (*Group, error) {
+	if suite == nil || suite.isRFC5054() {
+		return nil, ErrUnsupportedSuite
+	}
+	bitLength, err := modulusBitLength(modulusBytes, bitLength)
+	if err != nil {
+		return nil, err
+	}
+	generatorInt := big.NewInt(2)
+	if err := checkModulus(bitLength, generatorInt, toInt(modulusBytes)); err != nil {
+		return nil, err
+	}
+	return newGroup(suite, modulusBytes, generatorInt, bitLength)
+}
+
+// newGroup creates a group from a modulus that has already been validated.
+func newGroup(suite *Suite, modulusBytes []byte, generatorInt *big.Int, bitLength int) (*Group, error) {
+	modulusInt := suite.toInt(modulusBytes)
+	multiplier, err := suite.computeMultiplier(generatorInt, modulusInt, bitLength)
+	if err != nil {
+		return nil, err
+	}
+
+	modulusMinusOneInt := big.NewInt(0).Sub(modulusInt, big.NewInt(1))
+	modulusMinusOneNat := new(saferith.Nat).SetBig(modulusMinusOneInt, bitLength)
+	return &Group{
+		suite:              suite,
+		modulusBytes:       append([]byte{}, modulusBytes...),
+		bitLength:          bitLength,
+		generator:          generatorInt,
+		generatorNat:       new(saferith.Nat).SetBig(generatorInt, bitLength),
+		modulusInt:         modulusInt,
+		modulusMinusOneInt: modulusMinusOneInt,
+		modulusMinusOneNat: modulusMinusOneNat,
+		modulus:            suite.toModulus(modulusBytes),
+		modulusMinusOne:    saferith.ModulusFromNat(modulusMinusOneNat),
+		multiplier:         multiplier,
+	}, nil
+}
+
+// NewGroupFromSigned creates a new group from the signed modulus. The signature
+// is verified against server key.
+func NewGroupFromSigned(signedModulus string, bitLength int) (*Group, error) {
+	modulus, err := readClearSignedMessage(signedModulus)
+	if err != nil {
+		return nil, err
+	}
+	modulusData, err := base64.StdEncoding.DecodeString(modulus)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewGroup(modulusData, bitLength)
+}
+
+// Suite returns the SRP variant of the group.
+func (g *Group) Suite() *Suite {
+	return g.suite
+}
+
+// Modulus returns a copy of the raw modulus of the group, encoded by its suite.
+func (g *Group) Modulus() []byte {
+	return append([]byte{}, g.modulusBytes...)
+}
+
+// BitLength returns the size of the modulus in bits.
+func (g *Group) BitLength() int {
+	return g.bitLength
+}
+
+// sharedGroup returns group if it matches the raw modulus and bit length, or
+// validates a new group otherwise. A bitLength of 0 matches any group of the
+// modulus.
+func sharedGroup(group *Group, modulusBytes []byte, bitLength int) (*Group, error) {
+	if group != nil && group.matches(modulusBytes, bitLength) {
+		return group, nil
+	}
+	return NewGroup(modulusBytes, bitLength)
+}
+
+// suiteFor returns the suite of group if it matches the raw modulus, or the
+// Proton suite otherwise.
+func suiteFor(group *Group, modulusBytes []byte) *Suite {
+	if group != nil && group.matches(modulusBytes, 0) {
+		return group.suite
+	}
+	return SuiteProton
+}
+
+// ID returns an identifier of the modulus, the hex SHA-256 of the raw modulus.
+func (g *Group) ID() string {
+	return modulusID(g.modulusBytes)
+}
+
+func (g *Group) toInt(arr []byte) *big.Int {
+	return g.suite.toInt(arr)
+}
+
+func (g *Group) fromInt(num *big.Int) []byte {
+	return g.suite.fromInt(g.bitLength, num)
+}
+
+func (g *Group) toNat(arr []byte) *saferith.Nat {
+	return g.suite.toNat(arr)
+}
+
+func (g *Group) fromNat(nat *saferith.Nat) []byte {
+	return g.suite.fromNat(g.bitLength, nat)
+}
+
+func (g *Group) matches(modulusBytes []byte, bitLength int) bool {
+	return (bitLength == 0 || g.bitLength == bitLength) && bytes.Equal(g.modulusBytes, modulusBytes)
+}
+
+// modulusBitLength returns the size in bits of the raw modulus, checked against
+// bitLength unless it is 0.
+func modulusBitLength(modulusBytes []byte, bitLength int) (int, error) {
+	size := len(modulusBytes) * 8
+	if size == 0 || bitLength != 0 && bitLength != size {
+		return 0, ErrModulusSize
+	}
+	return size, nil
+}
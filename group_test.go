@@ -0,0 +1,329 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+// testModulus3072 is a 3072 bits safe prime, 3 mod 8, in the little-endian
+// base64 encoding of the Proton suite.
+const testModulus3072 = "" +
+	"u4oJLcLRIdNTsg3GkBjNJ+tm0aqv4kMdS/l0dwB3/q4bKP9o0pev36B+s9tuTD+jCcKDS9e2IwXg" +
+	"iENgaXuko5pEbvLaK0Qyi9HPU9bz98FtQzOfFq9HSFFUixMOu1x3aGPfkXx8SNWQKIuFjMw+3bsk" +
+	"w5lpiEDil5VEFDyk4SYFJjkGN04XTKkvFKzk62tjKeprfXazFjgKGerqwIPzaRCOrMo//jgeLw7k" +
+	"AEaWD2UmRAOdyp43EScsUmBgrl1LdjJ41Fk15f/3kQoRSk6LT401YYtG+7lCOBI5RIY77FHxv58K" +
+	"STnY9y1maG0wKa/+5RnVQZ/+vTu0OD0tv0G6LPNWBGzxKdWz/lJ3XnSIbdeGDkgfQrE/0acVFH50" +
+	"UAkf4FvO1ajUp28fqQasOHqfAF7ktGb9Njs/Rfra17s4OHDWKuU+znHZOMB2hp+BpP5eCzs02rzu" +
+	"MERZQNOezzk6SSMojtEz7EuZNmL5BqwZ5GQX/9bhFR2bq9UEwFE+3fKK"
+
+// testModulus4096 is a 4096 bits safe prime, 3 mod 8, in the little-endian
+// base64 encoding of the Proton suite.
+const testModulus4096 = "" +
+	"60cgoo9qFh+gCfaMNo7jRloh+th1bWCn2QJnCVGz/y7X0Nq+wFO5Q17Kwtj7/KCYEabcz3v81zlr" +
+	"fn6Tio4HKrP36M9HzNIuE0C7M1ANWP1rAo91VlJeekdaG8WyjAgH4Aq7XXRzMTqRm2qD9Sv7DF3A" +
+	"03yG+JhipOhWmuYodhC2UDPC7crWppvYUaVQTz6XHnXhL4ZJUE3BXiVwuLSj4NYTevvkD8Igm++z" +
+	"jOgycRSrd9TofWjFj8bgjmK9LOomUVtYYCqwqEbjkmAYtrhOtVNWuHwDAQayHROt1Yw633suCtn1" +
+	"7bjhY0Ojfea/FWXKNMQ/u0OnCXHVy9mNBU90Pz2hwjlLVEjPEsebP4GYO3HKPwsb6Md9DOrMFn1h" +
+	"E1SkJDmoI+BE492mLkln3qfFigi22VsjkuohjIvor/nRji2kNRv84UeluuVXDPiWGGjqBmsetGfc" +
+	"ECFX6Zl1sy3cL3EP8i4LUbW13vZk9NCEfu669C+bIypL04vEQEt8ssyjlWhtKmUj3ttpM22ue80s" +
+	"CI+lbxR31OnjU7pvyn5G2JfgsFGqu3y/0sC0WW0dIshkO3FQBrPhOO3vv7y73FTFrkYgh4rAkJoP" +
+	"ChCs3UKJGSClBmB2xfbzSQh8u0TnLSGnrG0uDMKhOp5iKWrfLS0suPoLv4KJxo9HnOs1H8Nuzes="
+
+func TestNewGroup(t *testing.T) {
+	modulus, err := base64.StdEncoding.DecodeString(testModulus)
+	if err != nil {
+		t.Fatal("Expected no error but have ", err)
+	}
+
+	group, err := NewGroup(modulus, 2048)
+	if err != nil {
+		t.Fatal("Expected no error but have ", err)
+	}
+	if group.BitLength() != 2048 {
+		t.Fatal("Expected a 2048 bits group but have ", group.BitLength())
+	}
+	if group.modulusMinusOne.Nat().Eq(group.modulusMinusOneNat) != 1 {
+		t.Fatal("Expected the group to cache the N-1 modulus")
+	}
+
+	if _, err := NewGroup(modulus, 1024); !errors.Is(err, ErrModulusSize) {
+		t.Fatal("Expected the ErrModulusSize but have ", err)
+	}
+
+	notPrime := group.Modulus()
+	notPrime[0] ^= 0x08
+	if _, err := NewGroup(notPrime, 2048); !errors.Is(err, ErrModulusNotSafePrime) {
+		t.Fatal("Expected the ErrModulusNotSafePrime but have ", err)
+	}
+
+	notThreeModEight := group.Modulus()
+	notThreeModEight[0] ^= 0x04
+	if _, err := NewGroup(notThreeModEight, 2048); !errors.Is(err, ErrModulusNot3Mod8) {
+		t.Fatal("Expected the ErrModulusNot3Mod8 but have ", err)
+	}
+	if group.Modulus()[0] == notPrime[0] {
+		t.Fatal("Expected the group modulus to be copied")
+	}
+
+	signedGroup, err := NewGroupFromSigned(testModulusClearSign, 2048)
+	if err != nil {
+		t.Fatal("Expected no error but have ", err)
+	}
+	if !signedGroup.matches(modulus, 2048) {
+		t.Fatal("Expected the signed group to match the raw modulus")
+	}
+}
+
+func TestSharedGroup(t *testing.T) {
+	group, err := NewGroupFromSigned(testModulusClearSign, 2048)
+	if err != nil {
+		t.Fatal("Expected no error while creating group, have ", err)
+	}
+
+	key, err := NewPasswordKey(4, "jakubqa", []byte("abc123"), "yKlc5/CvObfoiw==", testModulusClearSign, WithGroup(group))
+	if err != nil {
+		t.Fatal("Expected no error while hashing password, have ", err)
+	}
+	verifier, err := key.newAuth(nil).GenerateVerifier(2048)
+	if err != nil {
+		t.Fatal("Expected no error while generating verifier, have ", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		server, err := NewServerWithOptions(group.Modulus(), verifier, 2048, WithGroup(group))
+		if err != nil {
+			t.Fatal("Expected no error while creating server, have ", err)
+		}
+		if server.group != group {
+			t.Fatal("Expected the server to share the group")
+		}
+		challenge, err := server.GenerateChallenge()
+		if err != nil {
+			t.Fatal("Expected no error while generating challenge, have ", err)
+		}
+
+		auth := key.newAuth(challenge)
+		if shared, err := auth.groupFor(2048); err != nil || shared != group {
+			t.Fatal("Expected the auth to share the group, have ", err)
+		}
+		proofs, err := auth.GenerateProofs(2048)
+		if err != nil {
+			t.Fatal("Expected no error while generating client proofs, have ", err)
+		}
+		serverProof, err := server.VerifyProofs(proofs.ClientEphemeral, proofs.ClientProof)
+		if err != nil {
+			t.Fatal("Expected no error while verifying client proofs, have ", err)
+		}
+		if err := proofs.VerifyServerProof(serverProof); err != nil {
+			t.Fatal("Expected no error while verifying server proof, have ", err)
+		}
+	}
+
+	// A group of another modulus is ignored
+	auth := key.newAuth(nil)
+	auth.Modulus = append([]byte{}, auth.Modulus...)
+	auth.Modulus[0] ^= 0x08
+	if _, err := auth.groupFor(2048); err == nil {
+		t.Fatal("Expected an error for a modulus that does not match the shared group")
+	}
+}
+
+func TestNewGroupForSuite(t *testing.T) {
+	modulus, err := base64.StdEncoding.DecodeString(testModulus)
+	if err != nil {
+		t.Fatal("Expected no error but have ", err)
+	}
+
+	for _, expander := range []Expander{SHAKE256Expander, BLAKE2bExpander} {
+		suite := NewSuite("proton-xof", expander)
+		group, err := NewGroupForSuite(suite, modulus, 2048)
+		if err != nil {
+			t.Fatal("Expected no error while creating group, have ", err)
+		}
+		if group.Suite() != suite {
+			t.Fatal("Expected the group to use the custom suite")
+		}
+
+		salt, err := RandomBytes(SaltLength)
+		if err != nil {
+			t.Fatal("Expected no error while generating salt, have ", err)
+		}
+		key, err := NewPasswordKeyForGroup(group, 4, "jakubqa", []byte("abc123"), salt)
+		if err != nil {
+			t.Fatal("Expected no error while hashing password, have ", err)
+		}
+		protonHash, err := HashPassword(4, []byte("abc123"), "jakubqa", salt, modulus)
+		if err != nil {
+			t.Fatal("Expected no error while hashing password, have ", err)
+		}
+		if bytes.Equal(key.HashedPassword, protonHash) {
+			t.Fatal("Expected the custom suite to expand the password hash with its expander")
+		}
+
+		verifier, err := key.GenerateVerifier(2048)
+		if err != nil {
+			t.Fatal("Expected no error while generating verifier, have ", err)
+		}
+		server, err := NewServerWithOptions(modulus, verifier, 2048, WithGroup(group))
+		if err != nil {
+			t.Fatal("Expected no error while creating server, have ", err)
+		}
+		serverEphemeral, err := server.GenerateChallenge()
+		if err != nil {
+			t.Fatal("Expected no error while generating challenge, have ", err)
+		}
+		proofs, err := key.GenerateProofs(serverEphemeral, 2048)
+		if err != nil {
+			t.Fatal("Expected no error while generating proofs, have ", err)
+		}
+		serverProof, err := server.VerifyProofs(proofs.ClientEphemeral, proofs.ClientProof)
+		if err != nil {
+			t.Fatal("Expected no error while verifying client proof, have ", err)
+		}
+		if err := proofs.VerifyServerProof(serverProof); err != nil {
+			t.Fatal("Expected no error while verifying server proof, have ", err)
+		}
+	}
+
+	if _, err := NewGroupForSuite(SuiteRFC5054SHA256, modulus, 2048); !errors.Is(err, ErrUnsupportedSuite) {
+		t.Fatal("Expected the ErrUnsupportedSuite but have ", err)
+	}
+}
+
+func TestInferBitLength(t *testing.T) {
+	modulus, err := base64.StdEncoding.DecodeString(testModulus)
+	if err != nil {
+		t.Fatal("Expected no error but have ", err)
+	}
+
+	group, err := NewGroup(modulus, 0)
+	if err != nil {
+		t.Fatal("Expected no error while creating group, have ", err)
+	}
+	if group.BitLength() != 2048 {
+		t.Fatal("Expected a 2048 bits group but have ", group.BitLength())
+	}
+
+	key, err := NewPasswordKey(4, "jakubqa", []byte("abc123"), "yKlc5/CvObfoiw==", testModulusClearSign)
+	if err != nil {
+		t.Fatal("Expected no error while hashing password, have ", err)
+	}
+	verifier, err := key.GenerateVerifier(0)
+	if err != nil {
+		t.Fatal("Expected no error while generating verifier, have ", err)
+	}
+	server, err := NewServer(modulus, verifier, 0)
+	if err != nil {
+		t.Fatal("Expected no error while creating server, have ", err)
+	}
+	serverEphemeral, err := server.GenerateChallenge()
+	if err != nil {
+		t.Fatal("Expected no error while generating challenge, have ", err)
+	}
+	proofs, err := key.GenerateProofs(serverEphemeral, 0)
+	if err != nil {
+		t.Fatal("Expected no error while generating proofs, have ", err)
+	}
+	if _, err := server.VerifyProofs(proofs.ClientEphemeral, proofs.ClientProof); err != nil {
+		t.Fatal("Expected no error while verifying client proof, have ", err)
+	}
+
+	for _, bitLength := range []int{1024, 3072, 4096} {
+		if _, err := key.GenerateVerifier(bitLength); !errors.Is(err, ErrModulusSize) {
+			t.Fatal("Expected the ErrModulusSize while generating verifier but have ", err)
+		}
+		if _, err := key.GenerateProofs(serverEphemeral, bitLength); !errors.Is(err, ErrModulusSize) {
+			t.Fatal("Expected the ErrModulusSize while generating proofs but have ", err)
+		}
+		if _, err := NewServer(modulus, verifier, bitLength); !errors.Is(err, ErrModulusSize) {
+			t.Fatal("Expected the ErrModulusSize while creating server but have ", err)
+		}
+	}
+}
+
+func TestLargeGroups(t *testing.T) {
+	var groups []*Group
+	for _, encoded := range []string{testModulus3072, testModulus4096} {
+		modulus, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			t.Fatal("Expected no error but have ", err)
+		}
+		group, err := NewGroup(modulus, 0)
+		if err != nil {
+			t.Fatal("Expected no error while creating group, have ", err)
+		}
+		groups = append(groups, group)
+	}
+	for _, bitLength := range []int{3072, 4096} {
+		group, err := RFC5054Group(bitLength, SuiteRFC5054SHA256)
+		if err != nil {
+			t.Fatal("Expected no error while creating group, have ", err)
+		}
+		groups = append(groups, group)
+	}
+
+	for _, group := range groups {
+		salt, err := RandomBytes(SaltLength)
+		if err != nil {
+			t.Fatal("Expected no error while generating salt, have ", err)
+		}
+		key, err := NewPasswordKeyForGroup(group, 4, "jakubqa", []byte("abc123"), salt)
+		if err != nil {
+			t.Fatal("Expected no error while hashing password, have ", err)
+		}
+		verifier, err := key.GenerateVerifier(0)
+		if err != nil {
+			t.Fatal("Expected no error while generating verifier, have ", err)
+		}
+		if len(verifier) != group.BitLength()/8 {
+			t.Fatalf("Expected a %d bytes verifier but have %d", group.BitLength()/8, len(verifier))
+		}
+
+		server, err := NewServerWithOptions(group.Modulus(), verifier, 0, WithGroup(group), WithUsername("jakubqa"), WithSalt(salt))
+		if err != nil {
+			t.Fatal("Expected no error while creating server, have ", err)
+		}
+		serverEphemeral, err := server.GenerateChallenge()
+		if err != nil {
+			t.Fatal("Expected no error while generating challenge, have ", err)
+		}
+		proofs, err := key.GenerateProofs(serverEphemeral, 0)
+		if err != nil {
+			t.Fatal("Expected no error while generating proofs, have ", err)
+		}
+		if group.Suite() == SuiteProton && len(proofs.ClientProof) != group.BitLength()/8 {
+			t.Fatalf("Expected a %d bytes client proof but have %d", group.BitLength()/8, len(proofs.ClientProof))
+		}
+		serverProof, err := server.VerifyProofs(proofs.ClientEphemeral, proofs.ClientProof)
+		if err != nil {
+			t.Fatal("Expected no error while verifying client proof, have ", err)
+		}
+		if err := proofs.VerifyServerProof(serverProof); err != nil {
+			t.Fatal("Expected no error while verifying server proof, have ", err)
+		}
+	}
+}
@@ -23,15 +23,16 @@
 package srp
 
 import (
-	"bytes"
 	"crypto/md5"
 	"crypto/sha512"
 	"encoding/base64"
 	"encoding/hex"
-	"errors"
+	"io"
 	"strings"
 
 	"github.com/ProtonMail/bcrypt"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/sha3"
 )
 
 //based64DotSlash Bcrypt uses an adapted base64 alphabet (using . instead of +, starting with ./ and with no padding).
@@ -51,18 +52,65 @@ func bcryptHash(password []byte, encodedSalt string) (hashed []byte, err error)
 	return bcrypt.HashBytes(password, []byte("$2y$10$"+encodedSalt))
 }
 
-// expandHash extends the byte data for SRP flow
-func expandHash(data []byte) []byte {
-	part0 := sha512.Sum512(append(data, 0))
-	part1 := sha512.Sum512(append(data, 1))
-	part2 := sha512.Sum512(append(data, 2))
-	part3 := sha512.Sum512(append(data, 3))
-	return bytes.Join([][]byte{
-		part0[:],
-		part1[:],
-		part2[:],
-		part3[:],
-	}, []byte{})
+// Expander expands data into a hash of size bytes. The Proton suites expand
+// every hash to the size of the modulus, so any hash or XOF that can produce
+// outputs of arbitrary size can back a suite, see NewSuite.
+type Expander interface {
+	Expand(data []byte, size int) []byte
+}
+
+// ExpanderFunc adapts an ordinary function to the Expander interface.
+type ExpanderFunc func(data []byte, size int) []byte
+
+// Expand calls f(data, size).
+func (f ExpanderFunc) Expand(data []byte, size int) []byte {
+	return f(data, size)
+}
+
+var (
+	// SHA512Expander concatenates SHA-512(data | i) for i = 0, 1, ... It is the
+	// original Proton expansion, 256 bytes for 2048 bits moduli.
+	SHA512Expander Expander = ExpanderFunc(expandSHA512)
+
+	// SHAKE256Expander reads the expansion from the SHAKE256 XOF.
+	SHAKE256Expander Expander = ExpanderFunc(expandSHAKE256)
+
+	// BLAKE2bExpander reads the expansion from the BLAKE2Xb XOF.
+	BLAKE2bExpander Expander = ExpanderFunc(expandBLAKE2b)
+)
+
+// defaultExpansionSize is the size of the password hash when the modulus is
+// too short to set it, as for the original 2048 bits expansion.
+const defaultExpansionSize = 256
+
+func expandSHA512(data []byte, size int) []byte {
+	input := append(append([]byte{}, data...), 0)
+	expanded := make([]byte, 0, size+sha512.Size)
+	for i := 0; len(expanded) < size; i++ {
+		input[len(data)] = byte(i)
+		part := sha512.Sum512(input)
+		expanded = append(expanded, part[:]...)
+	}
+	return expanded[:size]
+}
+
+func expandSHAKE256(data []byte, size int) []byte {
+	expanded := make([]byte, size)
+	sha3.ShakeSum256(expanded, data)
+	return expanded
+}
+
+func expandBLAKE2b(data []byte, size int) []byte {
+	xof, err := blake2b.NewXOF(uint32(size), nil)
+	if err != nil {
+		panic(err) // Only for sizes of 4 GiB and more
+	}
+	_, _ = xof.Write(data)
+	expanded := make([]byte, size)
+	if _, err := io.ReadFull(xof, expanded); err != nil {
+		panic(err)
+	}
+	return expanded
 }
 
 // MailboxPassword get mailbox password hash
@@ -84,20 +132,35 @@ func MailboxPassword(password []byte, salt []byte) (hashed []byte, err error) {
 // * 0, 1, 2: userName and modulus
 // * 3, 4: salt and modulus
 func HashPassword(authVersion int, password []byte, userName string, salt, modulus []byte) ([]byte, error) {
+	return hashPassword(SHA512Expander, authVersion, password, userName, salt, modulus)
+}
+
+// hashPassword is HashPassword with the given expansion. The hash is expanded to
+// the size of the modulus.
+func hashPassword(expander Expander, authVersion int, password []byte, userName string, salt, modulus []byte) ([]byte, error) {
 	switch authVersion {
 	case 4, 3:
-		return hashPasswordVersion3(password, salt, modulus)
+		return hashPasswordVersion3(expander, password, salt, modulus)
 	case 2:
-		return hashPasswordVersion2(password, userName, modulus)
+		return hashPasswordVersion2(expander, password, userName, modulus)
 	case 1:
-		return hashPasswordVersion1(password, userName, modulus)
+		return hashPasswordVersion1(expander, password, userName, modulus)
 	case 0:
-		return hashPasswordVersion0(password, userName, modulus)
+		return hashPasswordVersion0(expander, password, userName, modulus)
 	default:
-		return nil, errors.New("pmapi: unsupported auth version")
+		return nil, ErrUnsupportedVersion
 	}
 }
 
+// expandPasswordHash expands the bcrypt output and the modulus.
+func expandPasswordHash(expander Expander, crypted, modulus []byte) []byte {
+	size := len(modulus)
+	if size < defaultExpansionSize {
+		size = defaultExpansionSize
+	}
+	return expander.Expand(append(crypted, modulus...), size)
+}
+
 // cleanUserName returns the input string in lower-case without characters `_`,
 // `.` and `-`.
 func cleanUserName(userName string) string {
@@ -107,21 +170,24 @@ func cleanUserName(userName string) string {
 	return strings.ToLower(userName)
 }
 
-func hashPasswordVersion3(password []byte, salt, modulus []byte) (res []byte, err error) {
+func hashPasswordVersion3(expander Expander, password []byte, salt, modulus []byte) (res []byte, err error) {
+	if err := checkSalt(3, salt); err != nil {
+		return nil, err
+	}
 	encodedSalt := based64DotSlash.EncodeToString(append(salt, []byte("proton")...))
 	crypted, err := bcryptHash(password, encodedSalt)
 	if err != nil {
 		return
 	}
 
-	return expandHash(append(crypted, modulus...)), nil
+	return expandPasswordHash(expander, crypted, modulus), nil
 }
 
-func hashPasswordVersion2(password []byte, userName string, modulus []byte) (res []byte, err error) {
-	return hashPasswordVersion1(password, cleanUserName(userName), modulus)
+func hashPasswordVersion2(expander Expander, password []byte, userName string, modulus []byte) (res []byte, err error) {
+	return hashPasswordVersion1(expander, password, cleanUserName(userName), modulus)
 }
 
-func hashPasswordVersion1(password []byte, userName string, modulus []byte) (res []byte, err error) {
+func hashPasswordVersion1(expander Expander, password []byte, userName string, modulus []byte) (res []byte, err error) {
 	prehashed := md5.Sum([]byte(strings.ToLower(userName)))
 	encodedSalt := hex.EncodeToString(prehashed[:])
 	crypted, err := bcryptHash(password, encodedSalt)
@@ -129,10 +195,10 @@ func hashPasswordVersion1(password []byte, userName string, modulus []byte) (res
 		return
 	}
 
-	return expandHash(append(crypted, modulus...)), nil
+	return expandPasswordHash(expander, crypted, modulus), nil
 }
 
-func hashPasswordVersion0(password []byte, userName string, modulus []byte) (res []byte, err error) {
+func hashPasswordVersion0(expander Expander, password []byte, userName string, modulus []byte) (res []byte, err error) {
 	b64Hash := make([]byte, 88) // 64 bytes in base64
 	userAndPass := append([]byte(strings.ToLower(userName)), password...)
 	defer clear(userAndPass)
@@ -140,7 +206,7 @@ func hashPasswordVersion0(password []byte, userName string, modulus []byte) (res
 	defer clear(prehashed[:])
 	base64.StdEncoding.Encode(b64Hash, prehashed[:])
 	defer clear(b64Hash)
-	return hashPasswordVersion1(b64Hash, userName, modulus)
+	return hashPasswordVersion1(expander, b64Hash, userName, modulus)
 }
 
 func clear(w []byte) {
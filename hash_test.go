@@ -23,6 +23,9 @@
 package srp
 
 import (
+	"bytes"
+	"crypto/sha512"
+	"errors"
 	"reflect"
 	"testing"
 )
@@ -100,7 +103,7 @@ func TestHashPassword(t *testing.T) {
 		want    []byte
 		wantErr bool
 	}{
-		// TODO: Add test cases.
+		{name: "unsupported version", args: args{authVersion: 5, password: "abc123"}, want: nil, wantErr: true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -109,9 +112,48 @@ func TestHashPassword(t *testing.T) {
 				t.Errorf("HashPassword() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			if tt.wantErr && !errors.Is(err, ErrUnsupportedVersion) {
+				t.Errorf("HashPassword() error = %v, want %v", err, ErrUnsupportedVersion)
+				return
+			}
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("HashPassword() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func TestExpanders(t *testing.T) {
+	data := []byte("expand me")
+
+	var original []byte
+	for i := byte(0); i < 4; i++ {
+		part := sha512.Sum512(append(append([]byte{}, data...), i))
+		original = append(original, part[:]...)
+	}
+	if got := SHA512Expander.Expand(data, 256); !bytes.Equal(got, original) {
+		t.Errorf("SHA512Expander.Expand() = %x, want %x", got, original)
+	}
+	if got := SHA512Expander.Expand(data, 512); !bytes.Equal(got[:256], original) {
+		t.Errorf("SHA512Expander.Expand() = %x, want prefix %x", got, original)
+	}
+
+	for name, expander := range map[string]Expander{
+		"sha512":   SHA512Expander,
+		"shake256": SHAKE256Expander,
+		"blake2b":  BLAKE2bExpander,
+	} {
+		for _, size := range []int{256, 384, 512} {
+			got := expander.Expand(data, size)
+			if len(got) != size {
+				t.Errorf("%s Expand() has %d bytes, want %d", name, len(got), size)
+			}
+			if !bytes.Equal(got, expander.Expand(data, size)) {
+				t.Errorf("%s Expand() is not deterministic", name)
+			}
+			if bytes.Equal(got, expander.Expand([]byte("expand you"), size)) {
+				t.Errorf("%s Expand() ignores the data", name)
+			}
+		}
+	}
+}
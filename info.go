@@ -0,0 +1,66 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+import (
+	"encoding/base64"
+	"encoding/json"
+)
+
+// AuthInfo is the response of the auth info API call, holding everything a
+// client needs to answer the SRP challenge.
+type AuthInfo struct {
+	Version         int    `json:"Version"`
+	Modulus         string `json:"Modulus"`
+	ServerEphemeral string `json:"ServerEphemeral"`
+	Salt            string `json:"Salt"`
+	SRPSession      string `json:"SRPSession"`
+}
+
+// AuthRequest is the body of the auth API call, holding the base64 client
+// ephemeral and proof for the SRP session.
+type AuthRequest struct {
+	ClientEphemeral string `json:"ClientEphemeral"`
+	ClientProof     string `json:"ClientProof"`
+	SRPSession      string `json:"SRPSession"`
+}
+
+// NewAuthFromInfo creates new Auth from the auth info API response.
+func NewAuthFromInfo(info *AuthInfo, username string, password []byte, opts ...Option) (*Auth, error) {
+	return NewAuthWithOptions(info.Version, username, password, info.Salt, info.Modulus, info.ServerEphemeral, opts...)
+}
+
+// AuthRequest returns the auth API request answering the SRP session.
+func (p *Proofs) AuthRequest(srpSession string) *AuthRequest {
+	return &AuthRequest{
+		ClientEphemeral: base64.StdEncoding.EncodeToString(p.ClientEphemeral),
+		ClientProof:     base64.StdEncoding.EncodeToString(p.ClientProof),
+		SRPSession:      srpSession,
+	}
+}
+
+// MarshalAuthRequest returns the JSON body of the auth API request answering
+// the SRP session.
+func (p *Proofs) MarshalAuthRequest(srpSession string) ([]byte, error) {
+	return json.Marshal(p.AuthRequest(srpSession))
+}
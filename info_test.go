@@ -0,0 +1,75 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+)
+
+func TestNewAuthFromInfo(t *testing.T) {
+	body, err := json.Marshal(map[string]interface{}{
+		"Code":            1000,
+		"Version":         4,
+		"Modulus":         testModulusClearSign,
+		"ServerEphemeral": testServerEphemeral,
+		"Salt":            "yKlc5/CvObfoiw==",
+		"SRPSession":      "b7953c6a26d97a8f7a673afb79e6e9ce",
+	})
+	if err != nil {
+		t.Fatal("Expected no error but have ", err)
+	}
+
+	var info AuthInfo
+	if err := json.Unmarshal(body, &info); err != nil {
+		t.Fatal("Expected no error while decoding auth info, have ", err)
+	}
+
+	auth, err := NewAuthFromInfo(&info, "jakubqa", []byte("abc123"), WithRandReader(rand.New(rand.NewSource(42))))
+	if err != nil {
+		t.Fatal("Expected no error while creating auth, have ", err)
+	}
+	proofs, err := auth.GenerateProofs(2048)
+	if err != nil {
+		t.Fatal("Expected no error while generating proofs, have ", err)
+	}
+
+	body, err = proofs.MarshalAuthRequest(info.SRPSession)
+	if err != nil {
+		t.Fatal("Expected no error while encoding auth request, have ", err)
+	}
+	var request map[string]string
+	if err := json.Unmarshal(body, &request); err != nil {
+		t.Fatal("Expected no error while decoding auth request, have ", err)
+	}
+	if request["ClientProof"] != testClientProof {
+		t.Fatalf("Expected client proof\n\t'%s'\nbut have\n\t'%s'", testClientProof, request["ClientProof"])
+	}
+	if request["SRPSession"] != info.SRPSession {
+		t.Fatalf("Expected SRP session '%s' but have '%s'", info.SRPSession, request["SRPSession"])
+	}
+	if request["ClientEphemeral"] == "" {
+		t.Fatal("Expected the client ephemeral to be set")
+	}
+}
@@ -0,0 +1,201 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Default parameters of NewDefaultLimiter: bursts of 10 challenges refilled at
+// one every 30 seconds, and failed verifications blocking the key for 1 second,
+// doubled on each consecutive failure up to 15 minutes.
+const (
+	DefaultLimiterBurst      = 10
+	DefaultLimiterInterval   = 30 * time.Second
+	DefaultLimiterBackoff    = time.Second
+	DefaultLimiterMaxBackoff = 15 * time.Minute
+)
+
+// Limiter limits the online password guesses against a key, such as a
+// username or a session. It is set on a server with WithLimiter: the server
+// calls Allow with its username, or the key set with WithLimiterKey, before
+// issuing a challenge, and Record after each verification. Implementations must
+// be safe for concurrent use.
+type Limiter interface {
+	// Allow returns nil if a new challenge can be issued for key, or a
+	// *RateLimitError otherwise.
+	Allow(key string) error
+	// Record reports the result of a verification for key.
+	Record(key string, verified bool)
+}
+
+// RateLimitError is returned by a Limiter refusing a challenge. It wraps
+// ErrRateLimited.
+type RateLimitError struct {
+	// RetryAfter is the time after which the key may be allowed again.
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return ErrRateLimited.Error() + " (retry after " + strconv.FormatInt(int64(e.RetryAfter/time.Millisecond), 10) + "ms)"
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return ErrRateLimited
+}
+
+// MemoryLimiter is a Limiter keeping its state in memory. Each key has a token
+// bucket: a challenge takes a token, and tokens are refilled at a fixed
+// interval up to the burst size. On top of it, each failed verification blocks
+// the key for an exponential backoff, reset by a successful verification.
+// Keys idle long enough to be back to a full bucket are forgotten.
+type MemoryLimiter struct {
+	burst               int
+	interval            time.Duration
+	backoff, maxBackoff time.Duration
+	now                 func() time.Time
+
+	mu      sync.Mutex
+	keys    map[string]*limiterKey
+	cleaned time.Time
+}
+
+type limiterKey struct {
+	tokens   float64
+	updated  time.Time
+	failures int
+	blocked  time.Time // until
+}
+
+// NewMemoryLimiter creates a limiter allowing bursts of burst challenges per
+// key, with one token refilled every interval. Failed verifications block the
+// key for backoff, doubled on each consecutive failure up to maxBackoff.
+func NewMemoryLimiter(burst int, interval, backoff, maxBackoff time.Duration) *MemoryLimiter {
+	return &MemoryLimiter{
+		burst:      burst,
+		interval:   interval,
+		backoff:    backoff,
+		maxBackoff: maxBackoff,
+		now:        time.Now,
+		keys:       make(map[string]*limiterKey),
+	}
+}
+
+// NewDefaultLimiter creates a MemoryLimiter with the default parameters.
+func NewDefaultLimiter() *MemoryLimiter {
+	return NewMemoryLimiter(DefaultLimiterBurst, DefaultLimiterInterval, DefaultLimiterBackoff, DefaultLimiterMaxBackoff)
+}
+
+// Allow takes a token for key, unless the bucket is empty or the key is
+// blocked after a failed verification.
+func (l *MemoryLimiter) Allow(key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.cleanup(now)
+	state := l.refill(key, now)
+	if now.Before(state.blocked) {
+		return &RateLimitError{RetryAfter: state.blocked.Sub(now)}
+	}
+	if state.tokens < 1 {
+		return &RateLimitError{RetryAfter: time.Duration((1 - state.tokens) * float64(l.interval))}
+	}
+	state.tokens--
+	return nil
+}
+
+// Record resets the backoff of key on success, and extends it on failure.
+func (l *MemoryLimiter) Record(key string, verified bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	state := l.refill(key, now)
+	if verified {
+		state.failures = 0
+		state.blocked = time.Time{}
+		return
+	}
+	state.failures++
+	state.blocked = now.Add(l.backoffFor(state.failures))
+}
+
+// Len returns the number of keys tracked by the limiter.
+func (l *MemoryLimiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.keys)
+}
+
+// refill returns the state of key with the tokens refilled up to now.
+func (l *MemoryLimiter) refill(key string, now time.Time) *limiterKey {
+	state, ok := l.keys[key]
+	if !ok {
+		state = &limiterKey{tokens: float64(l.burst), updated: now}
+		l.keys[key] = state
+		return state
+	}
+	if elapsed := now.Sub(state.updated); elapsed > 0 && l.interval > 0 {
+		state.tokens += float64(elapsed) / float64(l.interval)
+	}
+	if state.tokens > float64(l.burst) {
+		state.tokens = float64(l.burst)
+	}
+	state.updated = now
+	return state
+}
+
+// backoffFor returns backoff * 2^(failures-1), capped at maxBackoff.
+func (l *MemoryLimiter) backoffFor(failures int) time.Duration {
+	backoff := l.backoff
+	for i := 1; i < failures && backoff < l.maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > l.maxBackoff {
+		backoff = l.maxBackoff
+	}
+	return backoff
+}
+
+// idle returns the time after which an unused key has a full bucket and its
+// failures can be forgotten.
+func (l *MemoryLimiter) idle() time.Duration {
+	return time.Duration(l.burst)*l.interval + l.maxBackoff
+}
+
+// cleanup forgets the idle keys, at most once per idle period.
+func (l *MemoryLimiter) cleanup(now time.Time) {
+	idle := l.idle()
+	if now.Sub(l.cleaned) < idle {
+		return
+	}
+	l.cleaned = now
+	for key, state := range l.keys {
+		if now.Sub(state.updated) >= idle && !now.Before(state.blocked) {
+			delete(l.keys, key)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func expectRetryAfter(t *testing.T, err error, retryAfter time.Duration) {
+	t.Helper()
+	var rateLimitErr *RateLimitError
+	if !errors.As(err, &rateLimitErr) || !errors.Is(err, ErrRateLimited) || rateLimitErr.RetryAfter != retryAfter {
+		t.Fatalf("Expected a rate limit error with retry after %v but have %v", retryAfter, err)
+	}
+}
+
+func TestMemoryLimiterTokenBucket(t *testing.T) {
+	now := time.Now()
+	limiter := NewMemoryLimiter(2, time.Minute, time.Second, time.Hour)
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if err := limiter.Allow("jakubqa"); err != nil {
+			t.Fatal("Expected the burst to be allowed but have ", err)
+		}
+	}
+	expectRetryAfter(t, limiter.Allow("jakubqa"), time.Minute)
+	if err := limiter.Allow("other"); err != nil {
+		t.Fatal("Expected keys to have their own bucket but have ", err)
+	}
+
+	now = now.Add(30 * time.Second)
+	expectRetryAfter(t, limiter.Allow("jakubqa"), 30*time.Second)
+	now = now.Add(30 * time.Second)
+	if err := limiter.Allow("jakubqa"); err != nil {
+		t.Fatal("Expected a refilled token to be allowed but have ", err)
+	}
+}
+
+func TestMemoryLimiterBackoff(t *testing.T) {
+	now := time.Now()
+	limiter := NewMemoryLimiter(100, time.Second, time.Second, 5*time.Second)
+	limiter.now = func() time.Time { return now }
+
+	for _, backoff := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if err := limiter.Allow("jakubqa"); err != nil {
+			t.Fatal("Expected the key to be allowed after its backoff but have ", err)
+		}
+		limiter.Record("jakubqa", false)
+		expectRetryAfter(t, limiter.Allow("jakubqa"), backoff)
+		now = now.Add(backoff)
+	}
+
+	limiter.Record("jakubqa", true)
+	if err := limiter.Allow("jakubqa"); err != nil {
+		t.Fatal("Expected no error after a successful verification, have ", err)
+	}
+	limiter.Record("jakubqa", false)
+	expectRetryAfter(t, limiter.Allow("jakubqa"), time.Second)
+
+	now = now.Add(limiter.idle())
+	if err := limiter.Allow("other"); err != nil {
+		t.Fatal("Expected no error, have ", err)
+	}
+	if limiter.Len() != 1 {
+		t.Fatal("Expected the idle keys to be forgotten but have ", limiter.Len())
+	}
+}
+
+func TestServerLimiter(t *testing.T) {
+	limiter := NewMemoryLimiter(2, time.Hour, time.Hour, time.Hour)
+	key, server, serverEphemeral := newTestChallenge(t, WithLimiter(limiter), WithUsername("jakubqa"))
+	if _, err := server.GenerateChallenge(); err != nil {
+		t.Fatal("Expected the same challenge to be returned again without a token but have ", err)
+	}
+
+	proofs, err := key.GenerateProofs(serverEphemeral, 0)
+	if err != nil {
+		t.Fatal("Expected no error while generating proofs, have ", err)
+	}
+	proofs.ClientProof[0] ^= 1
+	if _, err := server.VerifyProofs(proofs.ClientEphemeral, proofs.ClientProof); !errors.Is(err, ErrInvalidClientProof) {
+		t.Fatal("Expected the ErrInvalidClientProof but have ", err)
+	}
+
+	verifier, err := key.GenerateVerifier(0)
+	if err != nil {
+		t.Fatal("Expected no error while generating verifier, have ", err)
+	}
+	server, err = NewServerWithOptions(key.Modulus, verifier, 0, WithLimiter(limiter), WithUsername("jakubqa"))
+	if err != nil {
+		t.Fatal("Expected no error while creating server, have ", err)
+	}
+	if _, err := server.GenerateChallenge(); !errors.Is(err, ErrRateLimited) {
+		t.Fatal("Expected the failed verification to block the username but have ", err)
+	}
+}
+
+func TestServerLimiterKey(t *testing.T) {
+	limiter := NewMemoryLimiter(1, time.Hour, time.Hour, time.Hour)
+	key, err := NewPasswordKey(4, "jakubqa", []byte("abc123"), "yKlc5/CvObfoiw==", testModulusClearSign)
+	if err != nil {
+		t.Fatal("Expected no error while hashing password, have ", err)
+	}
+	verifier, err := key.GenerateVerifier(0)
+	if err != nil {
+		t.Fatal("Expected no error while generating verifier, have ", err)
+	}
+
+	server, err := NewServerWithOptions(key.Modulus, verifier, 0, WithLimiter(limiter))
+	if err != nil {
+		t.Fatal("Expected no error while creating server, have ", err)
+	}
+	if _, err := server.GenerateChallenge(); !errors.Is(err, ErrLimiterKeyRequired) {
+		t.Fatal("Expected the ErrLimiterKeyRequired without username but have ", err)
+	}
+	if limiter.Len() != 0 {
+		t.Fatal("Expected the empty key not to be limited")
+	}
+
+	for _, clientKey := range []string{"jakubqa 192.0.2.1", "jakubqa 192.0.2.2"} {
+		server, err := NewServerWithOptions(key.Modulus, verifier, 0, WithLimiter(limiter), WithUsername("jakubqa"), WithLimiterKey(clientKey))
+		if err != nil {
+			t.Fatal("Expected no error while creating server, have ", err)
+		}
+		if _, err := server.GenerateChallenge(); err != nil {
+			t.Fatal("Expected each client to have its own bucket but have ", err)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"sync"
+	"time"
+)
+
+// masterKeySessionIDLength is the size of the raw session IDs of master key
+// sessions: SessionIDLength random bytes followed by the issue time.
+const masterKeySessionIDLength = SessionIDLength + 8
+
+// ledgerSweepInterval is how often MemorySessionLedger forgets expired entries.
+const ledgerSweepInterval = time.Minute
+
+// SessionLedger records the consumed session IDs of master key sessions, so
+// that each challenge is verified only once across all nodes. Implementations
+// must be safe for concurrent use and shared by all the verifying nodes.
+type SessionLedger interface {
+	// Consume records the session ID until expires, or returns
+	// ErrChallengeAlreadyUsed if it is already recorded. It must be atomic,
+	// e.g. with SET NX in a shared cache.
+	Consume(sessionID string, expires time.Time) error
+}
+
+// NewMasterKeySession issues a challenge whose server secret is derived from an
+// HMAC-SHA512 of the session ID, username and verifier keyed by masterKey,
+// instead of being generated at random and stored. The session ID carries the
+// issue time, which is bound by the derivation, and the challenge lifetime set
+// with WithChallengeLifetime is required. Any node holding the master key can
+// verify the proofs with VerifyMasterKeySession.
+func NewMasterKeySession(masterKey, modulusBytes, verifier []byte, opts ...Option) (sessionID string, serverEphemeral []byte, err error) {
+	options := newOptions(opts)
+	if options.lifetime <= 0 {
+		return "", nil, ErrChallengeLifetimeRequired
+	}
+
+	id := make([]byte, masterKeySessionIDLength)
+	if _, err := io.ReadFull(randReaderOrDefault(options.randReader), id[:SessionIDLength]); err != nil {
+		return "", nil, err
+	}
+	binary.BigEndian.PutUint64(id[SessionIDLength:], uint64(clockOrDefault(options.clock)().UnixNano()))
+
+	server, err := newMasterKeyServer(masterKey, id, modulusBytes, verifier, options)
+	if err != nil {
+		return "", nil, err
+	}
+	defer server.wipe()
+	serverEphemeral, err = server.GenerateChallenge()
+	if err != nil {
+		return "", nil, err
+	}
+	return base64.RawURLEncoding.EncodeToString(id), serverEphemeral, nil
+}
+
+// VerifyMasterKeySession rebuilds the server of a session issued by
+// NewMasterKeySession and verifies the client proofs. The options must match
+// the ones of the issuing node, the challenge lifetime in particular, and the
+// clocks of the nodes must be synchronized. The session ID is consumed in the
+// ledger before the proofs are verified, so each challenge can be answered only
+// once. Rebuilding the challenge does not take a token of the limiter nor
+// notify the observer, which only see the verification. The server is returned
+// on success to access the shared session.
+func VerifyMasterKeySession(masterKey []byte, ledger SessionLedger, sessionID string, modulusBytes, verifier, clientEphemeral, clientProof []byte, opts ...Option) (server *Server, serverProof []byte, err error) {
+	if ledger == nil {
+		return nil, nil, ErrSessionLedgerRequired
+	}
+	options := newOptions(opts)
+	if options.lifetime <= 0 {
+		return nil, nil, ErrChallengeLifetimeRequired
+	}
+
+	id, err := base64.RawURLEncoding.DecodeString(sessionID)
+	if err != nil || len(id) != masterKeySessionIDLength {
+		return nil, nil, ErrSessionNotFound
+	}
+	issued := time.Unix(0, int64(binary.BigEndian.Uint64(id[SessionIDLength:])))
+	// Issue times in the future are only accepted within a lifetime of clock
+	// skew, which bounds how long the ledger keeps an entry.
+	if issued.After(clockOrDefault(options.clock)().Add(options.lifetime)) {
+		return nil, nil, ErrSessionNotFound
+	}
+	expires := issued.Add(options.lifetime)
+
+	if err := ledger.Consume(sessionID, expires); err != nil {
+		notifyVerification(options.observer, ServerEvent{Username: options.username, Err: err})
+		return nil, nil, err
+	}
+
+	server, err = newMasterKeyServer(masterKey, id, modulusBytes, verifier, options)
+	if err != nil {
+		return nil, nil, err
+	}
+	server.computeEphemeral()
+	server.expires = expires
+	serverProof, err = server.VerifyProofs(clientEphemeral, clientProof)
+	if err != nil {
+		return nil, nil, err
+	}
+	return server, serverProof, nil
+}
+
+// newMasterKeyServer creates the server of a master key session from the raw
+// session ID.
+func newMasterKeyServer(masterKey, sessionID, modulusBytes, verifier []byte, options *options) (*Server, error) {
+	if len(masterKey) == 0 {
+		return nil, ErrInvalidMasterKey
+	}
+	group, err := sharedGroup(options.group, modulusBytes, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := group.checkVerifier(verifier); err != nil {
+		return nil, err
+	}
+
+	secretBytes := deriveServerSecret(group, masterKey, sessionID, options.username, verifier)
+	defer clear(secretBytes)
+	return newServer(group, verifier, group.toNat(secretBytes), options), nil
+}
+
+// deriveServerSecret expands the HMAC of the length prefixed inputs to the size
+// of the modulus. Like the random secrets of NewServer, values out of bounds
+// are rejected and the derivation is retried with the next counter.
+func deriveServerSecret(group *Group, masterKey, sessionID []byte, username string, verifier []byte) []byte {
+	var buf bytes.Buffer
+	for counter := uint32(0); ; counter++ {
+		buf.Reset()
+		buf.WriteString("go-srp server secret\x00")
+		writeUint32(&buf, counter)
+		for _, field := range [][]byte{sessionID, []byte(username), verifier} {
+			writeUint32(&buf, uint32(len(field)))
+			buf.Write(field)
+		}
+
+		mac := hmac.New(sha512.New, masterKey)
+		_, _ = mac.Write(buf.Bytes())
+		digest := mac.Sum(nil)
+		secret := SHA512Expander.Expand(digest, group.bitLength/8)
+		clear(digest)
+		if group.checkSecret(secret) == nil {
+			return secret
+		}
+		clear(secret)
+	}
+}
+
+// MemorySessionLedger is a SessionLedger keeping the consumed session IDs in
+// memory until they expire. It only protects a single node.
+type MemorySessionLedger struct {
+	now func() time.Time
+
+	mu       sync.Mutex
+	sessions map[string]time.Time // expiry by session ID
+	swept    time.Time
+}
+
+// NewMemorySessionLedger creates an empty ledger.
+func NewMemorySessionLedger() *MemorySessionLedger {
+	return &MemorySessionLedger{
+		now:      time.Now,
+		sessions: make(map[string]time.Time),
+	}
+}
+
+// Consume records the session ID until expires, or returns
+// ErrChallengeAlreadyUsed if it is already recorded.
+func (l *MemorySessionLedger) Consume(sessionID string, expires time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.swept) >= ledgerSweepInterval {
+		l.swept = now
+		for id, sessionExpires := range l.sessions {
+			if !now.Before(sessionExpires) {
+				delete(l.sessions, id)
+			}
+		}
+	}
+	if sessionExpires, ok := l.sessions[sessionID]; ok && now.Before(sessionExpires) {
+		return ErrChallengeAlreadyUsed
+	}
+	l.sessions[sessionID] = expires
+	return nil
+}
+
+// Len returns the number of session IDs recorded, including the expired ones
+// not forgotten yet.
+func (l *MemorySessionLedger) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.sessions)
+}
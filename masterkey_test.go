@@ -0,0 +1,164 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMasterKeySession(t *testing.T) {
+	key, err := NewPasswordKey(4, "jakubqa", []byte("abc123"), "yKlc5/CvObfoiw==", testModulusClearSign)
+	if err != nil {
+		t.Fatal("Expected no error while hashing password, have ", err)
+	}
+	verifier, err := key.GenerateVerifier(0)
+	if err != nil {
+		t.Fatal("Expected no error while generating verifier, have ", err)
+	}
+	masterKey := []byte("server master key")
+	now := time.Now()
+	observer := &recordingObserver{}
+	limiter := NewMemoryLimiter(1, time.Hour, time.Second, time.Second)
+	opts := []Option{
+		WithUsername("jakubqa"),
+		WithChallengeLifetime(time.Minute),
+		WithClock(func() time.Time { return now }),
+		WithObserver(observer),
+		WithLimiter(limiter),
+	}
+	ledger := NewMemorySessionLedger()
+	ledger.now = func() time.Time { return now }
+
+	sessionID, serverEphemeral, err := NewMasterKeySession(masterKey, key.Modulus, verifier, opts...)
+	if err != nil {
+		t.Fatal("Expected no error while creating session, have ", err)
+	}
+	observer.expect(t, "issued")
+	proofs, err := key.GenerateProofs(serverEphemeral, 0)
+	if err != nil {
+		t.Fatal("Expected no error while generating proofs, have ", err)
+	}
+
+	// Another node rebuilds the server, without taking a limiter token or
+	// issuing the challenge again.
+	now = now.Add(30 * time.Second)
+	server, serverProof, err := VerifyMasterKeySession(masterKey, ledger, sessionID, key.Modulus, verifier, proofs.ClientEphemeral, proofs.ClientProof, opts...)
+	if err != nil {
+		t.Fatal("Expected no error while verifying session, have ", err)
+	}
+	observer.expect(t, "verified")
+	if err := proofs.VerifyServerProof(serverProof); err != nil {
+		t.Fatal("Expected no error while verifying server proof, have ", err)
+	}
+	if !server.IsCompleted() {
+		t.Fatal("Expected the server to be completed")
+	}
+
+	_, _, err = VerifyMasterKeySession(masterKey, ledger, sessionID, key.Modulus, verifier, proofs.ClientEphemeral, proofs.ClientProof, opts...)
+	if !errors.Is(err, ErrChallengeAlreadyUsed) {
+		t.Fatal("Expected the ErrChallengeAlreadyUsed when replaying the session but have ", err)
+	}
+	if event := observer.expect(t, "rejected"); event.Reason != RejectChallengeAlreadyUsed {
+		t.Fatal("Expected the replay to be rejected as RejectChallengeAlreadyUsed but have ", event.Reason)
+	}
+
+	// The remaining sessions fail, drop the limiter so that they are not backed off.
+	opts = opts[:len(opts)-1]
+	newSession := func() (string, *Proofs) {
+		t.Helper()
+		sessionID, serverEphemeral, err := NewMasterKeySession(masterKey, key.Modulus, verifier, opts...)
+		if err != nil {
+			t.Fatal("Expected no error while creating session, have ", err)
+		}
+		proofs, err := key.GenerateProofs(serverEphemeral, 0)
+		if err != nil {
+			t.Fatal("Expected no error while generating proofs, have ", err)
+		}
+		return sessionID, proofs
+	}
+	expectError := func(masterKey []byte, sessionID string, proofs *Proofs, target error) {
+		t.Helper()
+		_, _, err := VerifyMasterKeySession(masterKey, ledger, sessionID, key.Modulus, verifier, proofs.ClientEphemeral, proofs.ClientProof, opts...)
+		if !errors.Is(err, target) {
+			t.Fatalf("Expected %v but have %v", target, err)
+		}
+	}
+
+	sessionID, proofs = newSession()
+	now = now.Add(time.Minute)
+	expectError(masterKey, sessionID, proofs, ErrChallengeExpired)
+
+	sessionID, proofs = newSession()
+	expectError([]byte("other master key"), sessionID, proofs, ErrInvalidClientProof)
+
+	sessionID, proofs = newSession()
+	id, err := base64.RawURLEncoding.DecodeString(sessionID)
+	if err != nil {
+		t.Fatal("Expected no error while decoding session ID, have ", err)
+	}
+	binary.BigEndian.PutUint64(id[SessionIDLength:], uint64(now.Add(-time.Second).UnixNano()))
+	expectError(masterKey, base64.RawURLEncoding.EncodeToString(id), proofs, ErrInvalidClientProof)
+	binary.BigEndian.PutUint64(id[SessionIDLength:], uint64(now.Add(2*time.Minute).UnixNano()))
+	expectError(masterKey, base64.RawURLEncoding.EncodeToString(id), proofs, ErrSessionNotFound)
+	expectError(masterKey, sessionID[1:], proofs, ErrSessionNotFound)
+
+	if _, _, err := NewMasterKeySession(nil, key.Modulus, verifier, opts...); !errors.Is(err, ErrInvalidMasterKey) {
+		t.Fatal("Expected the ErrInvalidMasterKey but have ", err)
+	}
+	if _, _, err := NewMasterKeySession(masterKey, key.Modulus, verifier); !errors.Is(err, ErrChallengeLifetimeRequired) {
+		t.Fatal("Expected the ErrChallengeLifetimeRequired but have ", err)
+	}
+	_, _, err = NewMasterKeySession(masterKey, key.Modulus, make([]byte, len(verifier)), opts...)
+	expectInputError(t, err, "verifier", ErrVerifierOutOfBounds)
+	if _, _, err := VerifyMasterKeySession(masterKey, nil, sessionID, key.Modulus, verifier, nil, nil, opts...); !errors.Is(err, ErrSessionLedgerRequired) {
+		t.Fatal("Expected the ErrSessionLedgerRequired but have ", err)
+	}
+}
+
+func TestMemorySessionLedger(t *testing.T) {
+	now := time.Now()
+	ledger := NewMemorySessionLedger()
+	ledger.now = func() time.Time { return now }
+
+	if err := ledger.Consume("a", now.Add(time.Minute)); err != nil {
+		t.Fatal("Expected no error while consuming session, have ", err)
+	}
+	if err := ledger.Consume("a", now.Add(time.Minute)); !errors.Is(err, ErrChallengeAlreadyUsed) {
+		t.Fatal("Expected the ErrChallengeAlreadyUsed but have ", err)
+	}
+	if err := ledger.Consume("b", now.Add(time.Hour)); err != nil {
+		t.Fatal("Expected no error while consuming session, have ", err)
+	}
+
+	now = now.Add(time.Minute)
+	if err := ledger.Consume("c", now.Add(time.Minute)); err != nil {
+		t.Fatal("Expected no error while consuming session, have ", err)
+	}
+	if ledger.Len() != 2 {
+		t.Fatal("Expected the expired sessions to be forgotten but have ", ledger.Len())
+	}
+}
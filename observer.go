@@ -0,0 +1,153 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+import (
+	"errors"
+	"time"
+)
+
+// Observer is notified of the steps of the exchange of a Server, e.g. to record
+// metrics or an audit log. It is set with WithObserver. Each call of
+// VerifyProofs on a generated challenge reports exactly one of ProofVerified,
+// ProofRejected, EphemeralOutOfBounds or ChallengeExpired. Methods are called
+// synchronously and must not block.
+type Observer interface {
+	// ChallengeIssued is called when GenerateChallenge returns a server ephemeral.
+	ChallengeIssued(event ServerEvent)
+	// ProofVerified is called when VerifyProofs accepts the client proof.
+	ProofVerified(event ServerEvent)
+	// ProofRejected is called when VerifyProofs fails for event.Reason.
+	ProofRejected(event ServerEvent)
+	// EphemeralOutOfBounds is called when the client ephemeral is not in ]1, N-1[.
+	EphemeralOutOfBounds(event ServerEvent)
+	// ChallengeExpired is called when the client proofs arrive after the
+	// challenge lifetime.
+	ChallengeExpired(event ServerEvent)
+}
+
+// ServerEvent describes a step of the exchange reported to an Observer.
+type ServerEvent struct {
+	// Username is the username set with WithUsername, if any.
+	Username string
+	// Reason is the cause of the failure, for ProofRejected only.
+	Reason RejectReason
+	// Err is the error returned to the caller, nil on success.
+	Err error
+	// Exponentiation is the time spent in the modular exponentiations of the
+	// step: g^b for a challenge, (A * v^u)^b for a verification. It is zero if
+	// the step failed before any exponentiation.
+	Exponentiation time.Duration
+}
+
+// RejectReason is the cause of a rejected verification.
+type RejectReason int
+
+const (
+	// RejectInvalidClientProof the client proof does not match the password
+	RejectInvalidClientProof RejectReason = iota + 1
+	// RejectInvalidInput the client ephemeral or proof does not have the size of the group
+	RejectInvalidInput
+	// RejectInvalidEphemeral the ephemerals hash to a zero scrambling parameter
+	RejectInvalidEphemeral
+	// RejectChallengeAlreadyUsed the server already verified proofs for its challenge
+	RejectChallengeAlreadyUsed
+	// RejectSessionNotFound the session of the challenge does not exist, has
+	// expired or was already used, reported by the srphttp handlers
+	RejectSessionNotFound
+	// RejectOther any other failure, see ServerEvent.Err
+	RejectOther
+)
+
+// String returns the name of the reason, for logs and metric labels.
+func (r RejectReason) String() string {
+	switch r {
+	case RejectInvalidClientProof:
+		return "invalid_client_proof"
+	case RejectInvalidInput:
+		return "invalid_input"
+	case RejectInvalidEphemeral:
+		return "invalid_ephemeral"
+	case RejectChallengeAlreadyUsed:
+		return "challenge_already_used"
+	case RejectSessionNotFound:
+		return "session_not_found"
+	default:
+		return "other"
+	}
+}
+
+// NopObserver ignores every event. It can be embedded by observers interested
+// in a few events only.
+type NopObserver struct{}
+
+// ChallengeIssued does nothing.
+func (NopObserver) ChallengeIssued(ServerEvent) {}
+
+// ProofVerified does nothing.
+func (NopObserver) ProofVerified(ServerEvent) {}
+
+// ProofRejected does nothing.
+func (NopObserver) ProofRejected(ServerEvent) {}
+
+// EphemeralOutOfBounds does nothing.
+func (NopObserver) EphemeralOutOfBounds(ServerEvent) {}
+
+// ChallengeExpired does nothing.
+func (NopObserver) ChallengeExpired(ServerEvent) {}
+
+// notifyVerification reports the result of a verification to observer, if any.
+func notifyVerification(observer Observer, event ServerEvent) {
+	if observer == nil {
+		return
+	}
+	err := event.Err
+	switch {
+	case err == nil:
+		observer.ProofVerified(event)
+	case errors.Is(err, ErrEphemeralOutOfBounds):
+		observer.EphemeralOutOfBounds(event)
+	case errors.Is(err, ErrChallengeExpired):
+		observer.ChallengeExpired(event)
+	default:
+		event.Reason = rejectReason(err)
+		observer.ProofRejected(event)
+	}
+}
+
+func rejectReason(err error) RejectReason {
+	switch {
+	case errors.Is(err, ErrInvalidClientProof):
+		return RejectInvalidClientProof
+	case errors.Is(err, ErrInvalidLength):
+		return RejectInvalidInput
+	case errors.Is(err, ErrInvalidEphemeral):
+		return RejectInvalidEphemeral
+	case errors.Is(err, ErrChallengeAlreadyUsed):
+		return RejectChallengeAlreadyUsed
+	case errors.Is(err, ErrSessionNotFound):
+		return RejectSessionNotFound
+	default:
+		return RejectOther
+	}
+}
@@ -0,0 +1,111 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type recordingObserver struct {
+	events []string
+	last   ServerEvent
+}
+
+func (o *recordingObserver) record(name string, event ServerEvent) {
+	o.events = append(o.events, name)
+	o.last = event
+}
+
+func (o *recordingObserver) ChallengeIssued(event ServerEvent) { o.record("issued", event) }
+func (o *recordingObserver) ProofVerified(event ServerEvent)   { o.record("verified", event) }
+func (o *recordingObserver) ProofRejected(event ServerEvent)   { o.record("rejected", event) }
+func (o *recordingObserver) EphemeralOutOfBounds(event ServerEvent) {
+	o.record("out of bounds", event)
+}
+func (o *recordingObserver) ChallengeExpired(event ServerEvent) { o.record("expired", event) }
+
+func (o *recordingObserver) expect(t *testing.T, name string) ServerEvent {
+	t.Helper()
+	if len(o.events) == 0 || o.events[len(o.events)-1] != name {
+		t.Fatalf("Expected the %s event but have %v", name, o.events)
+	}
+	o.events = nil
+	return o.last
+}
+
+func TestObserver(t *testing.T) {
+	observer := &recordingObserver{}
+	key, server, serverEphemeral := newTestChallenge(t, WithObserver(observer), WithUsername("jakubqa"))
+	if event := observer.expect(t, "issued"); event.Username != "jakubqa" || event.Exponentiation <= 0 {
+		t.Fatal("Expected the challenge event to carry the username and exponentiation time but have ", event)
+	}
+	proofs, err := key.GenerateProofs(serverEphemeral, 0)
+	if err != nil {
+		t.Fatal("Expected no error while generating proofs, have ", err)
+	}
+	if _, err := server.VerifyProofs(proofs.ClientEphemeral, proofs.ClientProof); err != nil {
+		t.Fatal("Expected no error while verifying proofs, have ", err)
+	}
+	if event := observer.expect(t, "verified"); event.Err != nil || event.Exponentiation <= 0 {
+		t.Fatal("Expected the verified event to carry the exponentiation time but have ", event)
+	}
+	_, _ = server.VerifyProofs(proofs.ClientEphemeral, proofs.ClientProof)
+	if event := observer.expect(t, "rejected"); event.Reason != RejectChallengeAlreadyUsed {
+		t.Fatal("Expected a replay to be rejected as RejectChallengeAlreadyUsed but have ", event.Reason)
+	}
+
+	_, server, serverEphemeral = newTestChallenge(t, WithObserver(observer))
+	proofs, err = key.GenerateProofs(serverEphemeral, 0)
+	if err != nil {
+		t.Fatal("Expected no error while generating proofs, have ", err)
+	}
+	proofs.ClientProof[0] ^= 1
+	_, _ = server.VerifyProofs(proofs.ClientEphemeral, proofs.ClientProof)
+	event := observer.expect(t, "rejected")
+	if event.Reason != RejectInvalidClientProof || !errors.Is(event.Err, ErrInvalidClientProof) || event.Exponentiation <= 0 {
+		t.Fatal("Expected a wrong proof to be rejected as RejectInvalidClientProof but have ", event)
+	}
+
+	_, server, _ = newTestChallenge(t, WithObserver(observer))
+	_, _ = server.VerifyProofs(proofs.ClientEphemeral[1:], proofs.ClientProof)
+	if event := observer.expect(t, "rejected"); event.Reason != RejectInvalidInput || event.Exponentiation != 0 {
+		t.Fatal("Expected a short ephemeral to be rejected as RejectInvalidInput but have ", event)
+	}
+
+	_, server, _ = newTestChallenge(t, WithObserver(observer))
+	_, _ = server.VerifyProofs(make([]byte, len(proofs.ClientEphemeral)), proofs.ClientProof)
+	observer.expect(t, "out of bounds")
+
+	now := time.Now()
+	_, server, _ = newTestChallenge(t, WithObserver(observer), WithChallengeLifetime(time.Minute), WithClock(func() time.Time { return now }))
+	now = now.Add(time.Minute)
+	_, _ = server.VerifyProofs(proofs.ClientEphemeral, proofs.ClientProof)
+	observer.expect(t, "expired")
+
+	if RejectSessionNotFound.String() != "session_not_found" || RejectReason(0).String() != "other" {
+		t.Fatal("Expected the reasons to have stable names")
+	}
+	var _ Observer = NopObserver{}
+}
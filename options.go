@@ -0,0 +1,162 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+import (
+	"io"
+	"time"
+)
+
+// Option sets an optional parameter of a client or server instance.
+type Option func(*options)
+
+type options struct {
+	randReader io.Reader
+	group      *Group
+	version    *int
+	username   string
+	salt       []byte
+	lifetime   time.Duration
+	clock      func() time.Time
+	observer   Observer
+	limiter    Limiter
+	limiterKey string
+}
+
+// WithRandReader sets the source of randomness used by the instance instead of
+// the package level RandReader.
+func WithRandReader(reader io.Reader) Option {
+	return func(o *options) {
+		o.randReader = reader
+	}
+}
+
+// WithGroup shares a group already validated with NewGroup, so that the modulus
+// checks and the multiplier are not computed again. The group is only used if it
+// matches the modulus of the instance.
+func WithGroup(group *Group) Option {
+	return func(o *options) {
+		o.group = group
+	}
+}
+
+// WithVersion sets the auth version of the verifiers generated by NewVerifierBundle.
+// On a server, it is the auth version of the verifier, see Server.NeedsUpgrade.
+func WithVersion(version int) Option {
+	return func(o *options) {
+		o.version = &version
+	}
+}
+
+// WithUsername sets the username hashed by the legacy auth versions 0 to 2 in
+// the verifiers generated by NewVerifierBundle. Servers of the RFC 5054 suites
+// also need it, as the username is bound by the client proof.
+func WithUsername(username string) Option {
+	return func(o *options) {
+		o.username = username
+	}
+}
+
+// WithSalt sets the raw salt of the verifier. Servers of the RFC 5054 suites
+// need it, as the salt is bound by the client proof.
+func WithSalt(salt []byte) Option {
+	return func(o *options) {
+		o.salt = salt
+	}
+}
+
+// WithChallengeLifetime sets how long a server accepts the client proofs after
+// GenerateChallenge. Past the lifetime, VerifyProofs returns ErrChallengeExpired
+// and wipes the server secret. By default challenges do not expire.
+func WithChallengeLifetime(lifetime time.Duration) Option {
+	return func(o *options) {
+		o.lifetime = lifetime
+	}
+}
+
+// WithClock sets the clock used for the challenge lifetime instead of time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.clock = now
+	}
+}
+
+// WithObserver sets the observer notified of the steps of the exchange of a
+// server.
+func WithObserver(observer Observer) Option {
+	return func(o *options) {
+		o.observer = observer
+	}
+}
+
+// WithLimiter sets the limiter of a server, keyed by the username set with
+// WithUsername or the key set with WithLimiterKey. GenerateChallenge returns a
+// *RateLimitError when the limiter refuses a new challenge, and
+// ErrLimiterKeyRequired if the key is empty. VerifyProofs records each result.
+func WithLimiter(limiter Limiter) Option {
+	return func(o *options) {
+		o.limiter = limiter
+	}
+}
+
+// WithLimiterKey sets the key of the limiter instead of the username. Keying
+// by username alone lets anyone exhaust the bucket of an account and lock its
+// owner out, a key combining the username and the client, such as its IP
+// address, limits each client instead.
+func WithLimiterKey(key string) Option {
+	return func(o *options) {
+		o.limiterKey = key
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// randReaderOrDefault returns reader, or the package level RandReader if unset.
+func randReaderOrDefault(reader io.Reader) io.Reader {
+	if reader != nil {
+		return reader
+	}
+	return RandReader
+}
+
+// clockOrDefault returns clock, or time.Now if unset.
+func clockOrDefault(clock func() time.Time) func() time.Time {
+	if clock != nil {
+		return clock
+	}
+	return time.Now
+}
+
+// limiterKeyOrDefault returns key, or the username if unset.
+func limiterKeyOrDefault(key, username string) string {
+	if key != "" {
+		return key
+	}
+	return username
+}
@@ -0,0 +1,158 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+)
+
+// PasswordKey stores the hashed password for a given modulus, salt and auth
+// version. Hashing the password is the expensive part of the client flow, the
+// key can be reused to answer any number of server ephemerals, e.g. on retries
+// or re-authentication, without running bcrypt again.
+type PasswordKey struct {
+	Modulus, HashedPassword []byte
+	Version                 int
+	randReader              io.Reader
+	group                   *Group
+	username                string
+	salt                    []byte
+}
+
+// NewPasswordKey verifies the signed modulus and hashes the password. Salt is in
+// base64 format. Modulus is base64 with signature attached. The signature is
+// verified against server key. The version controls password hash algorithm.
+// The modulus is validated once here, unless the WithGroup group matches it,
+// and the password is hashed with the suite of the group, as the proofs are.
+func NewPasswordKey(version int, username string, password []byte, b64salt, signedModulus string, opts ...Option) (*PasswordKey, error) {
+	return newPasswordKey(context.Background(), version, username, password, b64salt, signedModulus, opts...)
+}
+
+// newPasswordKey verifies the signed modulus, validates it and hashes the
+// password, checking ctx between each step. The hash runs on a copy of the
+// password, see runWithContext.
+func newPasswordKey(ctx context.Context, version int, username string, password []byte, b64salt, signedModulus string, opts ...Option) (*PasswordKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	modulus, err := readClearSignedMessage(signedModulus)
+	if err != nil {
+		return nil, err
+	}
+	modulusData, err := base64.StdEncoding.DecodeString(modulus)
+	if err != nil {
+		return nil, err
+	}
+
+	var decodedSalt []byte
+	if version >= 3 {
+		decodedSalt, err = base64.StdEncoding.DecodeString(b64salt)
+		if err != nil {
+			return nil, err
+		}
+	}
+	options := newOptions(opts)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	group, err := sharedGroup(options.group, modulusData, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// The hash may outlive this call, work on a copy the caller cannot wipe.
+	passwordCopy := append([]byte{}, password...)
+	var hashedPassword []byte
+	err = runWithContext(ctx, func() (err error) {
+		defer clear(passwordCopy)
+		hashedPassword, err = group.suite.hashPassword(version, passwordCopy, username, decodedSalt, modulusData)
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &PasswordKey{
+		Modulus:        modulusData,
+		HashedPassword: hashedPassword,
+		Version:        version,
+		randReader:     options.randReader,
+		group:          group,
+		username:       username,
+		salt:           decodedSalt,
+	}, nil
+}
+
+// NewPasswordKeyForGroup hashes the password for the raw salt with the suite of
+// the group. The group is trusted as is, no signature is checked. This is the
+// entry point for the RFC 5054 suites, which ignore the version.
+func NewPasswordKeyForGroup(group *Group, version int, username string, password, salt []byte, opts ...Option) (*PasswordKey, error) {
+	hashedPassword, err := group.suite.hashPassword(version, password, username, salt, group.modulusBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PasswordKey{
+		Modulus:        group.Modulus(),
+		HashedPassword: hashedPassword,
+		Version:        version,
+		randReader:     newOptions(opts).randReader,
+		group:          group,
+		username:       username,
+		salt:           append([]byte{}, salt...),
+	}, nil
+}
+
+// NewAuth creates a new Auth answering the base64 server ephemeral.
+func (k *PasswordKey) NewAuth(serverEphemeral string) (*Auth, error) {
+	serverEphemeralData, err := base64.StdEncoding.DecodeString(serverEphemeral)
+	if err != nil {
+		return nil, err
+	}
+	return k.newAuth(serverEphemeralData), nil
+}
+
+// GenerateProofs calculates SRP proofs answering the raw server ephemeral.
+func (k *PasswordKey) GenerateProofs(serverEphemeral []byte, bitLength int) (*Proofs, error) {
+	return k.newAuth(serverEphemeral).GenerateProofs(bitLength)
+}
+
+// GenerateVerifier computes the verifier of the password.
+func (k *PasswordKey) GenerateVerifier(bitLength int) ([]byte, error) {
+	return k.newAuth(nil).GenerateVerifier(bitLength)
+}
+
+func (k *PasswordKey) newAuth(serverEphemeral []byte) *Auth {
+	return &Auth{
+		Modulus:         k.Modulus,
+		ServerEphemeral: serverEphemeral,
+		HashedPassword:  k.HashedPassword,
+		Version:         k.Version,
+		randReader:      k.randReader,
+		group:           k.group,
+		username:        k.username,
+		salt:            k.salt,
+	}
+}
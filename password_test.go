@@ -0,0 +1,156 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"math/rand"
+	"testing"
+)
+
+func TestPasswordKey(t *testing.T) {
+	key, err := NewPasswordKey(4, "jakubqa", []byte("abc123"), "yKlc5/CvObfoiw==", testModulusClearSign, WithRandReader(rand.New(rand.NewSource(42))))
+	if err != nil {
+		t.Fatal("Expected no error but have ", err)
+	}
+
+	auth, err := key.NewAuth(testServerEphemeral)
+	if err != nil {
+		t.Fatal("Expected no error but have ", err)
+	}
+	proofs, err := auth.GenerateProofs(2048)
+	if err != nil {
+		t.Fatal("Expected no error but have ", err)
+	}
+	expectedProof, err := base64.StdEncoding.DecodeString(testServerProof)
+	if err != nil {
+		t.Fatal("Expected no error but have ", err)
+	}
+	if !bytes.Equal(proofs.ExpectedServerProof, expectedProof) {
+		t.Fatalf("Expected server proof\n\t'%s'\nbut have\n\t'%s'",
+			testServerProof,
+			base64.StdEncoding.EncodeToString(proofs.ExpectedServerProof),
+		)
+	}
+
+	// The same key answers a new server ephemeral without rehashing the password
+	verifier, err := key.newAuth(nil).GenerateVerifier(2048)
+	if err != nil {
+		t.Fatal("Expected no error while generating verifier, have ", err)
+	}
+	server, err := NewServerFromSigned(testModulusClearSign, verifier, 2048)
+	if err != nil {
+		t.Fatal("Expected no error while creating server, have ", err)
+	}
+	challenge, err := server.GenerateChallenge()
+	if err != nil {
+		t.Fatal("Expected no error while generating challenge, have ", err)
+	}
+	proofs, err = key.GenerateProofs(challenge, 2048)
+	if err != nil {
+		t.Fatal("Expected no error while generating client proofs, have ", err)
+	}
+	serverProof, err := server.VerifyProofs(proofs.ClientEphemeral, proofs.ClientProof)
+	if err != nil {
+		t.Fatal("Expected no error while verifying client proofs, have ", err)
+	}
+	if err := proofs.VerifyServerProof(serverProof); err != nil {
+		t.Fatal("Expected no error while verifying server proof, have ", err)
+	}
+}
+
+func TestPasswordKeyCachesGroup(t *testing.T) {
+	key, err := NewPasswordKey(4, "jakubqa", []byte("abc123"), "yKlc5/CvObfoiw==", testModulusClearSign)
+	if err != nil {
+		t.Fatal("Expected no error but have ", err)
+	}
+	if key.group == nil {
+		t.Fatal("Expected the key to validate the modulus once and keep its group")
+	}
+	group, err := key.newAuth(nil).groupFor(0)
+	if err != nil {
+		t.Fatal("Expected no error but have ", err)
+	}
+	if group != key.group {
+		t.Fatal("Expected the proofs to reuse the group of the key")
+	}
+}
+
+func TestPasswordKeyGroupSuite(t *testing.T) {
+	group, err := NewGroupFromSigned(testModulusClearSign, 0)
+	if err != nil {
+		t.Fatal("Expected no error while creating group, have ", err)
+	}
+	shakeGroup, err := NewGroupForSuite(NewSuite("proton-shake256", SHAKE256Expander), group.Modulus(), 0)
+	if err != nil {
+		t.Fatal("Expected no error while creating group, have ", err)
+	}
+	salt, err := base64.StdEncoding.DecodeString("yKlc5/CvObfoiw==")
+	if err != nil {
+		t.Fatal("Expected no error while decoding salt, have ", err)
+	}
+
+	expected, err := NewPasswordKeyForGroup(shakeGroup, 4, "jakubqa", []byte("abc123"), salt)
+	if err != nil {
+		t.Fatal("Expected no error while hashing password, have ", err)
+	}
+	key, err := NewPasswordKey(4, "jakubqa", []byte("abc123"), "yKlc5/CvObfoiw==", testModulusClearSign, WithGroup(shakeGroup))
+	if err != nil {
+		t.Fatal("Expected no error while hashing password, have ", err)
+	}
+	if !bytes.Equal(key.HashedPassword, expected.HashedPassword) {
+		t.Fatal("Expected NewPasswordKey to hash with the suite of the group")
+	}
+	auth, err := NewAuthForVerifierWithOptions([]byte("abc123"), testModulusClearSign, salt, WithGroup(shakeGroup))
+	if err != nil {
+		t.Fatal("Expected no error while creating auth, have ", err)
+	}
+	if !bytes.Equal(auth.HashedPassword, expected.HashedPassword) {
+		t.Fatal("Expected NewAuthForVerifier to hash with the suite of the group")
+	}
+
+	bundle, err := NewVerifierBundle([]byte("abc123"), testModulusClearSign, WithGroup(shakeGroup))
+	if err != nil {
+		t.Fatal("Expected no error while generating verifier bundle, have ", err)
+	}
+	key, err = NewPasswordKeyForGroup(shakeGroup, bundle.Version, "", []byte("abc123"), bundle.Salt)
+	if err != nil {
+		t.Fatal("Expected no error while hashing password, have ", err)
+	}
+	verifier, err := key.GenerateVerifier(0)
+	if err != nil {
+		t.Fatal("Expected no error while generating verifier, have ", err)
+	}
+	if !bytes.Equal(bundle.Verifier, verifier) {
+		t.Fatal("Expected NewVerifierBundle to hash with the suite of the group")
+	}
+
+	plain, err := NewPasswordKey(4, "jakubqa", []byte("abc123"), "yKlc5/CvObfoiw==", testModulusClearSign)
+	if err != nil {
+		t.Fatal("Expected no error while hashing password, have ", err)
+	}
+	if bytes.Equal(plain.HashedPassword, expected.HashedPassword) {
+		t.Fatal("Expected the Proton suite to hash differently than the SHAKE256 one")
+	}
+}
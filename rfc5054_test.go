@@ -0,0 +1,236 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// RFC 5054 appendix B test vectors, for the 1024 bits group with SHA-1.
+var (
+	rfc5054Username = "alice"
+	rfc5054Password = []byte("password123")
+	rfc5054Salt     = "BEB25379 D1A8581E B5A72767 3A2441EE"
+	rfc5054K        = "7556AA04 5AEF2CDD 07ABAF0F 665C3E81 8913186F"
+	rfc5054X        = "94B7555A ABE9127C C58CCF49 93DB6CF8 4D16C124"
+	rfc5054V        = "" +
+		"7E273DE8 696FFC4F 4E337D05 B4B375BE B0DDE156 9E8FA00A 9886D812" +
+		"9BADA1F1 822223CA 1A605B53 0E379BA4 729FDC59 F105B478 7E5186F5" +
+		"C671085A 1447B52A 48CF1970 B4FB6F84 00BBF4CE BFBB1681 52E08AB5" +
+		"EA53D15C 1AFF87B2 B9DA6E04 E058AD51 CC72BFC9 033B564E 26480D78" +
+		"E955A5E2 9E7AB245 DB2BE315 E2099AFB"
+	rfc5054ClientSecret = "60975527 035CF2AD 1989806F 0407210B C81EDC04 E2762A56 AFD529DD DA2D4393"
+	rfc5054ServerSecret = "E487CB59 D31AC550 471E81F0 0F6928E0 1DDA08E9 74A004F4 9E61F5D1 05284D20"
+	rfc5054A            = "" +
+		"61D5E490 F6F1B795 47B0704C 436F523D D0E560F0 C64115BB 72557EC4" +
+		"4352E890 3211C046 92272D8B 2D1A5358 A2CF1B6E 0BFCF99F 921530EC" +
+		"8E393561 79EAE45E 42BA92AE ACED8251 71E1E8B9 AF6D9C03 E1327F44" +
+		"BE087EF0 6530E69F 66615261 EEF54073 CA11CF58 58F0EDFD FE15EFEA" +
+		"B349EF5D 76988A36 72FAC47B 0769447B"
+	rfc5054B = "" +
+		"BD0C6151 2C692C0C B6D041FA 01BB152D 4916A1E7 7AF46AE1 05393011" +
+		"BAF38964 DC46A067 0DD125B9 5A981652 236F99D9 B681CBF8 7837EC99" +
+		"6C6DA044 53728610 D0C6DDB5 8B318885 D7D82C7F 8DEB75CE 7BD4FBAA" +
+		"37089E6F 9C6059F3 88838E7A 00030B33 1EB76840 910440B1 B27AAEAE" +
+		"EB4012B7 D7665238 A8E3FB00 4B117B58"
+	rfc5054U = "CE38B959 3487DA98 554ED47D 70A7AE5F 462EF019"
+	rfc5054S = "" +
+		"B0DC82BA BCF30674 AE450C02 87745E79 90A3381F 63B387AA F271A10D" +
+		"233861E3 59B48220 F7C4693C 9AE12B0A 6F67809F 0876E2D0 13800D6C" +
+		"41BB59B6 D5979B5C 00A172B4 A2A5903A 0BDCAF8A 709585EB 2AFAFA8F" +
+		"3499B200 210DCC1F 10EB3394 3CD67FC8 8A2F39A4 BE5BEC4E C0A3212D" +
+		"C346D7E4 74B29EDE 8A469FFE CA686E5A"
+)
+
+func decodeRFC5054Hex(t *testing.T, s string) []byte {
+	t.Helper()
+	data, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		t.Fatal("Expected no error while decoding test vector, have ", err)
+	}
+	return data
+}
+
+func TestRFC5054Vectors(t *testing.T) {
+	group, err := RFC5054Group(1024, SuiteRFC5054SHA1)
+	if err != nil {
+		t.Fatal("Expected no error while creating group, have ", err)
+	}
+	salt := decodeRFC5054Hex(t, rfc5054Salt)
+
+	if multiplier := group.fromNat(group.multiplier); !bytes.Equal(trimNumber(multiplier), decodeRFC5054Hex(t, rfc5054K)) {
+		t.Fatalf("Expected multiplier %s but have %X", rfc5054K, multiplier)
+	}
+
+	// The client secret is read from the random reader as a big-endian number
+	// of the size of the modulus.
+	clientSecret := append(make([]byte, 96), decodeRFC5054Hex(t, rfc5054ClientSecret)...)
+	key, err := NewPasswordKeyForGroup(
+		group, 0, rfc5054Username, rfc5054Password, salt,
+		WithRandReader(bytes.NewReader(clientSecret)),
+	)
+	if err != nil {
+		t.Fatal("Expected no error while hashing password, have ", err)
+	}
+	if !bytes.Equal(key.HashedPassword, decodeRFC5054Hex(t, rfc5054X)) {
+		t.Fatalf("Expected password hash %s but have %X", rfc5054X, key.HashedPassword)
+	}
+
+	verifier, err := key.GenerateVerifier(1024)
+	if err != nil {
+		t.Fatal("Expected no error while generating verifier, have ", err)
+	}
+	if !bytes.Equal(verifier, decodeRFC5054Hex(t, rfc5054V)) {
+		t.Fatalf("Expected verifier %s but have %X", rfc5054V, verifier)
+	}
+
+	server, err := NewServerWithSecretWithOptions(
+		group.Modulus(), verifier, decodeRFC5054Hex(t, rfc5054ServerSecret), 1024,
+		WithGroup(group), WithUsername(rfc5054Username), WithSalt(salt),
+	)
+	if err != nil {
+		t.Fatal("Expected no error while creating server, have ", err)
+	}
+	serverEphemeral, err := server.GenerateChallenge()
+	if err != nil {
+		t.Fatal("Expected no error while generating challenge, have ", err)
+	}
+	if !bytes.Equal(serverEphemeral, decodeRFC5054Hex(t, rfc5054B)) {
+		t.Fatalf("Expected server ephemeral %s but have %X", rfc5054B, serverEphemeral)
+	}
+
+	proofs, err := key.GenerateProofs(serverEphemeral, 1024)
+	if err != nil {
+		t.Fatal("Expected no error while generating proofs, have ", err)
+	}
+	if !bytes.Equal(proofs.ClientEphemeral, decodeRFC5054Hex(t, rfc5054A)) {
+		t.Fatalf("Expected client ephemeral %s but have %X", rfc5054A, proofs.ClientEphemeral)
+	}
+	if scramble := group.computeScrambleParam(proofs.ClientEphemeral, serverEphemeral); !bytes.Equal(trimNumber(group.fromNat(scramble)), decodeRFC5054Hex(t, rfc5054U)) {
+		t.Fatalf("Expected scrambling parameter %s but have %X", rfc5054U, group.fromNat(scramble))
+	}
+	if !bytes.Equal(proofs.sharedSession, decodeRFC5054Hex(t, rfc5054S)) {
+		t.Fatalf("Expected premaster secret %s but have %X", rfc5054S, proofs.sharedSession)
+	}
+
+	serverProof, err := server.VerifyProofs(proofs.ClientEphemeral, proofs.ClientProof)
+	if err != nil {
+		t.Fatal("Expected no error while verifying client proof, have ", err)
+	}
+	if err := proofs.VerifyServerProof(serverProof); err != nil {
+		t.Fatal("Expected no error while verifying server proof, have ", err)
+	}
+	serverSession, err := server.GetSharedSession()
+	if err != nil {
+		t.Fatal("Expected no error while getting shared session, have ", err)
+	}
+	if !bytes.Equal(serverSession, decodeRFC5054Hex(t, rfc5054S)) {
+		t.Fatalf("Expected premaster secret %s but have %X", rfc5054S, serverSession)
+	}
+}
+
+func TestRFC5054Groups(t *testing.T) {
+	for _, bitLength := range []int{1024, 1536, 2048, 3072, 4096} {
+		group, err := RFC5054Group(bitLength, SuiteRFC5054SHA256)
+		if err != nil {
+			t.Fatalf("Expected no error while creating the %d bits group, have %v", bitLength, err)
+		}
+		modulus := new(big.Int).SetBytes(group.Modulus())
+		if modulus.BitLen() != bitLength {
+			t.Fatalf("Expected a %d bits modulus but have %d", bitLength, modulus.BitLen())
+		}
+		halfModulus := new(big.Int).Rsh(modulus, 1)
+		if !modulus.ProbablyPrime(10) || !halfModulus.ProbablyPrime(10) {
+			t.Fatalf("Expected the %d bits modulus to be a safe prime", bitLength)
+		}
+	}
+
+	if _, err := RFC5054Group(512, SuiteRFC5054SHA256); !errors.Is(err, ErrModulusSize) {
+		t.Fatal("Expected the ErrModulusSize but have ", err)
+	}
+	if _, err := RFC5054Group(2048, SuiteProton); !errors.Is(err, ErrUnsupportedSuite) {
+		t.Fatal("Expected the ErrUnsupportedSuite but have ", err)
+	}
+}
+
+func TestRFC5054Exchange(t *testing.T) {
+	group, err := RFC5054Group(2048, SuiteRFC5054SHA256)
+	if err != nil {
+		t.Fatal("Expected no error while creating group, have ", err)
+	}
+	salt, err := RandomBytes(SaltLength)
+	if err != nil {
+		t.Fatal("Expected no error while generating salt, have ", err)
+	}
+
+	key, err := NewPasswordKeyForGroup(group, 0, "jakubqa", []byte("abc123"), salt)
+	if err != nil {
+		t.Fatal("Expected no error while hashing password, have ", err)
+	}
+	verifier, err := key.GenerateVerifier(2048)
+	if err != nil {
+		t.Fatal("Expected no error while generating verifier, have ", err)
+	}
+
+	server, err := NewServerWithOptions(group.Modulus(), verifier, 2048, WithGroup(group), WithUsername("jakubqa"), WithSalt(salt))
+	if err != nil {
+		t.Fatal("Expected no error while creating server, have ", err)
+	}
+	serverEphemeral, err := server.GenerateChallenge()
+	if err != nil {
+		t.Fatal("Expected no error while generating challenge, have ", err)
+	}
+
+	proofs, err := key.GenerateProofs(serverEphemeral, 2048)
+	if err != nil {
+		t.Fatal("Expected no error while generating proofs, have ", err)
+	}
+	serverProof, err := server.VerifyProofs(proofs.ClientEphemeral, proofs.ClientProof)
+	if err != nil {
+		t.Fatal("Expected no error while verifying client proof, have ", err)
+	}
+	if err := proofs.VerifyServerProof(serverProof); err != nil {
+		t.Fatal("Expected no error while verifying server proof, have ", err)
+	}
+
+	wrongSalt, err := NewServerWithOptions(group.Modulus(), verifier, 2048, WithGroup(group), WithUsername("jakubqa"))
+	if err != nil {
+		t.Fatal("Expected no error while creating server, have ", err)
+	}
+	serverEphemeral, err = wrongSalt.GenerateChallenge()
+	if err != nil {
+		t.Fatal("Expected no error while generating challenge, have ", err)
+	}
+	proofs, err = key.GenerateProofs(serverEphemeral, 2048)
+	if err != nil {
+		t.Fatal("Expected no error while generating proofs, have ", err)
+	}
+	if _, err := wrongSalt.VerifyProofs(proofs.ClientEphemeral, proofs.ClientProof); !errors.Is(err, ErrInvalidClientProof) {
+		t.Fatal("Expected the ErrInvalidClientProof for a server without the salt but have ", err)
+	}
+}
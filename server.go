@@ -1,10 +1,11 @@
 package srp
 
 import (
-	"bytes"
 	"crypto/subtle"
 	"encoding/base64"
+	"io"
 	"math/big"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -15,25 +16,50 @@ import (
 
 // Server stores the internal state for the validation of SRP proofs.
 type Server struct {
-	generator, verifier, serverSecret, serverEphemeral, multiplier, modulus *saferith.Nat
-	sharedSession                                                           []byte
-	bitLength                                                               int
+	group                                   *Group
+	verifier, serverSecret, serverEphemeral *saferith.Nat
+	sharedSession                           []byte
+	username                                string
+	salt                                    []byte
+	lifetime                                time.Duration
+	clock                                   func() time.Time
+	randReader                              io.Reader
+	observer                                Observer
+	limiter                                 Limiter
+	limiterKey                              string
+	legacy                                  bool
+	expires                                 time.Time
+	used                                    bool
 }
 
-// NewServer creates a new server instance from the raw binary data.
+// NewServer creates a new server instance from the raw binary data. A bitLength
+// of 0 infers the size from the modulus.
 func NewServer(modulusBytes, verifier []byte, bitLength int) (*Server, error) {
-	modulusInt := toInt(modulusBytes)
-	modulusMinusOneInt := big.NewInt(0).Sub(modulusInt, big.NewInt(1))
-	modulusMinusOneNat := new(saferith.Nat).SetBig(modulusMinusOneInt, bitLength)
-	var err error
+	return NewServerWithOptions(modulusBytes, verifier, bitLength)
+}
+
+// NewServerWithOptions is NewServer with options, such as WithGroup, WithUsername
+// or WithLimiter.
+func NewServerWithOptions(modulusBytes, verifier []byte, bitLength int, opts ...Option) (*Server, error) {
+	options := newOptions(opts)
+	group, err := sharedGroup(options.group, modulusBytes, bitLength)
+	if err != nil {
+		return nil, err
+	}
+	if err := group.checkVerifier(verifier); err != nil {
+		return nil, err
+	}
+
+	randReader := randReaderOrDefault(options.randReader)
 	var secret *saferith.Nat
 	var secretInt *big.Int
 	var secretBytes []byte
+	bitLength = group.bitLength
 	lowerBoundNat := newNat(uint64(bitLength * 2))
 	for {
-		secretInt, err = rand.Int(RandReader, modulusMinusOneInt)
+		secretInt, err = rand.Int(randReader, group.modulusMinusOneInt)
 		if err != nil {
-			return nil, errors.Wrap(err, "Couldn't generate the secret")
+			return nil, errors.Wrap(err, "pm-srp: couldn't generate the secret")
 		}
 		secretBytes = fromInt(bitLength, secretInt)
 		secret = toNat(secretBytes)
@@ -41,52 +67,63 @@ func NewServer(modulusBytes, verifier []byte, bitLength int) (*Server, error) {
 		// Prevent g^a from being smaller than the modulus
 		// and a to be >= than N-1
 		notTooSmall, _, _ := secret.Cmp(lowerBoundNat)
-		_, _, notTooLarge := secret.Cmp(modulusMinusOneNat)
+		_, _, notTooLarge := secret.Cmp(group.modulusMinusOneNat)
 		if notTooSmall == 1 && notTooLarge == 1 {
 			break
 		}
 	}
-	multiplier, err := computeMultiplier(big.NewInt(2), toInt(modulusBytes), bitLength)
-	if err != nil {
-		return nil, err
-	}
-	return &Server{
-		generator:       newNat(2),
-		modulus:         toNat(modulusBytes),
-		serverSecret:    secret,
-		verifier:        toNat(verifier),
-		bitLength:       bitLength,
-		serverEphemeral: nil,
-		sharedSession:   nil,
-		multiplier:      multiplier,
-	}, nil
+	return newServer(group, verifier, secret, options), nil
 }
 
 // NewServerWithSecret creates a new server instance without generating a random secret from the raw binary data.
-// Use with caution as the secret should not be reused.
+// Use with caution as the secret should not be reused. NewMasterKeySession derives a fresh secret per session.
 func NewServerWithSecret(modulusBytes, verifier, secretBytes []byte, bitLength int) (*Server, error) {
-	secret := toNat(secretBytes)
-	if greaterThan, _, _ := secret.Cmp(newNat(uint64(bitLength * 2))); greaterThan != 1 {
-		return nil, errors.New("go-srp: invalid secret")
-	}
-	multiplier, err := computeMultiplier(big.NewInt(2), toInt(modulusBytes), bitLength)
+	return NewServerWithSecretWithOptions(modulusBytes, verifier, secretBytes, bitLength)
+}
+
+// NewServerWithSecretWithOptions is NewServerWithSecret with options.
+func NewServerWithSecretWithOptions(modulusBytes, verifier, secretBytes []byte, bitLength int, opts ...Option) (*Server, error) {
+	options := newOptions(opts)
+	group, err := sharedGroup(options.group, modulusBytes, bitLength)
 	if err != nil {
 		return nil, err
 	}
+	if err := group.checkVerifier(verifier); err != nil {
+		return nil, err
+	}
+	if err := group.checkSecret(secretBytes); err != nil {
+		return nil, err
+	}
+	return newServer(group, verifier, group.toNat(secretBytes), options), nil
+}
+
+// newServer creates a server for a validated verifier and secret.
+func newServer(group *Group, verifier []byte, secret *saferith.Nat, options *options) *Server {
 	return &Server{
-		generator:       newNat(2),
-		modulus:         toNat(modulusBytes),
+		group:           group,
 		serverSecret:    secret,
-		verifier:        toNat(verifier),
-		bitLength:       bitLength,
+		verifier:        group.toNat(verifier),
 		serverEphemeral: nil,
 		sharedSession:   nil,
-		multiplier:      multiplier,
-	}, nil
+		username:        options.username,
+		salt:            options.salt,
+		lifetime:        options.lifetime,
+		clock:           clockOrDefault(options.clock),
+		randReader:      options.randReader,
+		observer:        options.observer,
+		limiter:         options.limiter,
+		limiterKey:      limiterKeyOrDefault(options.limiterKey, options.username),
+		legacy:          options.version != nil && IsLegacyVersion(*options.version),
+	}
 }
 
 // NewServerFromSigned creates a new server instance from the signed modulus and the binary verifier.
 func NewServerFromSigned(signedModulus string, verifier []byte, bitLength int) (*Server, error) {
+	return NewServerFromSignedWithOptions(signedModulus, verifier, bitLength)
+}
+
+// NewServerFromSignedWithOptions is NewServerFromSigned with options.
+func NewServerFromSignedWithOptions(signedModulus string, verifier []byte, bitLength int, opts ...Option) (*Server, error) {
 	modulus, err := readClearSignedMessage(signedModulus)
 	if err != nil {
 		return nil, err
@@ -96,19 +133,46 @@ func NewServerFromSigned(signedModulus string, verifier []byte, bitLength int) (
 		return nil, err
 	}
 
-	return NewServer(modulusData, verifier, bitLength)
+	return NewServerWithOptions(modulusData, verifier, bitLength, opts...)
 }
 
 // GenerateChallenge is the first step for SRP exchange, and generates a valid challenge for the provided verifier.
+// The challenge lifetime, if any, starts on the first call, which also takes a token of the limiter.
 func (s *Server) GenerateChallenge() (serverEphemeral []byte, err error) {
-	mod := saferith.ModulusFromNat(s.modulus)
+	if s.used {
+		return nil, ErrChallengeAlreadyUsed
+	}
+	if s.serverEphemeral == nil && s.limiter != nil {
+		if s.limiterKey == "" {
+			return nil, ErrLimiterKeyRequired
+		}
+		if err := s.limiter.Allow(s.limiterKey); err != nil {
+			return nil, err
+		}
+	}
+	if s.serverEphemeral == nil && s.lifetime > 0 {
+		s.expires = s.clock().Add(s.lifetime)
+	}
+
+	exponentiation := s.computeEphemeral()
+	if s.observer != nil {
+		s.observer.ChallengeIssued(ServerEvent{Username: s.username, Exponentiation: exponentiation})
+	}
+
+	return s.group.fromNat(s.serverEphemeral), nil
+}
+
+// computeEphemeral computes B = k*v + g^b and returns the time spent in the
+// exponentiation.
+func (s *Server) computeEphemeral() time.Duration {
+	mod := s.group.modulus
+	start := time.Now()
 	s.serverEphemeral = new(saferith.Nat).ModAdd(
-		new(saferith.Nat).ModMul(s.multiplier, s.verifier, mod),
-		new(saferith.Nat).Exp(s.generator, s.serverSecret, mod),
+		new(saferith.Nat).ModMul(s.group.multiplier, s.verifier, mod),
+		new(saferith.Nat).Exp(s.group.generatorNat, s.serverSecret, mod),
 		mod,
 	)
-
-	return fromNat(s.bitLength, s.serverEphemeral), nil
+	return time.Since(start)
 }
 
 func computeBaseServerSide(clientEphemeral, verifier, scramblingParam *saferith.Nat, modulus *saferith.Modulus) *saferith.Nat {
@@ -125,70 +189,107 @@ func computeBaseServerSide(clientEphemeral, verifier, scramblingParam *saferith.
 }
 
 func computeSharedSecretServerSide(
-	bitLength int,
 	clientEphemeral, verifier, scramblingParam, serverSecret *saferith.Nat,
 	modulus *saferith.Modulus,
-) []byte {
+) *saferith.Nat {
 	base := computeBaseServerSide(
 		clientEphemeral,
 		verifier,
 		scramblingParam,
 		modulus,
 	)
-	sharedSession := new(saferith.Nat).Exp(
+	defer wipeNat(base)
+	return new(saferith.Nat).Exp(
 		base,
 		serverSecret,
 		modulus,
 	)
-	return fromNat(bitLength, sharedSession)
 }
 
 // VerifyProofs Verifies the client proof and - if valid - generates the shared secret and returnd the server proof.
 // It concludes the exchange in valid state if successful.
+// The server can verify a single attempt: the server secret is wiped whatever the result, and further calls return
+// ErrChallengeAlreadyUsed.
 func (s *Server) VerifyProofs(clientEphemeralBytes, clientProofBytes []byte) (serverProof []byte, err error) {
 	if s.serverEphemeral == nil {
-		return nil, errors.New("pm-srp: SRP server ephemeral is not generated")
+		return nil, ErrChallengeNotGenerated
+	}
+	event := ServerEvent{Username: s.username}
+	serverProof, event.Err = s.verifyProofs(clientEphemeralBytes, clientProofBytes, &event.Exponentiation)
+	notifyVerification(s.observer, event)
+	if s.limiter != nil && s.limiterKey != "" && !errors.Is(event.Err, ErrChallengeAlreadyUsed) {
+		s.limiter.Record(s.limiterKey, event.Err == nil)
+	}
+	return serverProof, event.Err
+}
+
+// verifyProofs is VerifyProofs for a generated challenge, adding the time spent
+// in exponentiations to exponentiation.
+func (s *Server) verifyProofs(clientEphemeralBytes, clientProofBytes []byte, exponentiation *time.Duration) ([]byte, error) {
+	if s.used {
+		return nil, ErrChallengeAlreadyUsed
 	}
+	s.used = true
+	defer s.wipe()
 
-	modulusMinusOne := new(saferith.Nat).Sub(s.modulus, newNat(1), s.bitLength)
-	clientEphemeral := toNat(clientEphemeralBytes)
-	greaterThanOne, _, _ := clientEphemeral.Cmp(newNat(1))
-	_, _, lessThanModulusMinusOne := clientEphemeral.Cmp(modulusMinusOne)
-	if greaterThanOne != 1 || lessThanModulusMinusOne != 1 {
-		return nil, errors.New("pm-srp: SRP client ephemeral is out of bounds")
+	if !s.expires.IsZero() && !s.clock().Before(s.expires) {
+		return nil, ErrChallengeExpired
 	}
 
-	scramblingParam := computeScrambleParam(clientEphemeralBytes, fromNat(s.bitLength, s.serverEphemeral))
+	if err := s.group.checkEphemeral("client ephemeral", clientEphemeralBytes); err != nil {
+		return nil, err
+	}
+	if err := s.group.checkProof("client proof", clientProofBytes); err != nil {
+		return nil, err
+	}
+
+	clientEphemeral := s.group.toNat(clientEphemeralBytes)
+	serverEphemeralBytes := s.group.fromNat(s.serverEphemeral)
+	scramblingParam := s.group.computeScrambleParam(clientEphemeralBytes, serverEphemeralBytes)
+	defer wipeNat(scramblingParam)
 	if _, isZero, _ := scramblingParam.Cmp(newNat(0)); isZero == 1 {
-		return nil, errors.New("pm-srp: SRP client ephemeral is invalid")
+		return nil, ErrInvalidEphemeral
 	}
 
-	modulus := saferith.ModulusFromNat(s.modulus)
-	s.sharedSession = computeSharedSecretServerSide(
-		s.bitLength,
+	start := time.Now()
+	sharedSecret := computeSharedSecretServerSide(
 		clientEphemeral,
 		s.verifier,
 		scramblingParam,
 		s.serverSecret,
-		modulus,
+		s.group.modulus,
 	)
+	*exponentiation += time.Since(start)
+	defer wipeNat(sharedSecret)
+	s.sharedSession = s.group.fromNat(sharedSecret)
 
-	expectedClientProof := expandHash(bytes.Join([][]byte{
-		clientEphemeralBytes,
-		fromNat(s.bitLength, s.serverEphemeral),
-		s.sharedSession,
-	}, []byte{}))
+	expectedClientProof := s.group.computeClientProof(s.username, s.salt, clientEphemeralBytes, serverEphemeralBytes, s.sharedSession)
 
 	if subtle.ConstantTimeCompare(expectedClientProof, clientProofBytes) == 0 {
+		clear(s.sharedSession)
 		s.sharedSession = nil
-		return nil, errors.New("pm-srp: invalid SRP client proof")
+		return nil, ErrInvalidClientProof
 	}
 
-	return expandHash(bytes.Join([][]byte{
-		clientEphemeralBytes,
-		clientProofBytes,
-		s.sharedSession,
-	}, []byte{})), nil
+	return s.group.computeServerProof(clientEphemeralBytes, expectedClientProof, s.sharedSession), nil
+}
+
+// wipe zeroes the server secret, which can no longer be used.
+func (s *Server) wipe() {
+	if s.serverSecret != nil {
+		wipeNat(s.serverSecret)
+		s.serverSecret = nil
+	}
+}
+
+// wipeNat zeroes every limb of nat.
+func wipeNat(nat *saferith.Nat) {
+	nat.SetBytes(make([]byte, (nat.AnnouncedLen()+7)/8))
+}
+
+// Username returns the username set with WithUsername.
+func (s *Server) Username() string {
+	return s.username
 }
 
 // IsCompleted returns true if the exchange has been concluded in valid state.
@@ -199,7 +300,7 @@ func (s *Server) IsCompleted() bool {
 // GetSharedSession returns the shared secret as byte if the session has concluded in valid state.
 func (s *Server) GetSharedSession() ([]byte, error) {
 	if !s.IsCompleted() {
-		return nil, errors.New("pm-srp: SRP is not completed")
+		return nil, ErrNotCompleted
 	}
 	return s.sharedSession, nil
 }
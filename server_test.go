@@ -0,0 +1,139 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestServerChallengeLifetime(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	clock := func() time.Time { return now }
+
+	key, server, serverEphemeral := newTestChallenge(t, WithChallengeLifetime(time.Minute), WithClock(clock))
+	proofs, err := key.GenerateProofs(serverEphemeral, 0)
+	if err != nil {
+		t.Fatal("Expected no error while generating proofs, have ", err)
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := server.VerifyProofs(proofs.ClientEphemeral, proofs.ClientProof); !errors.Is(err, ErrChallengeExpired) {
+		t.Fatal("Expected the ErrChallengeExpired but have ", err)
+	}
+	if server.serverSecret != nil {
+		t.Fatal("Expected the server secret to be wiped")
+	}
+	now = now.Add(-time.Minute)
+	if _, err := server.VerifyProofs(proofs.ClientEphemeral, proofs.ClientProof); !errors.Is(err, ErrChallengeAlreadyUsed) {
+		t.Fatal("Expected the ErrChallengeAlreadyUsed once wiped but have ", err)
+	}
+
+	key, server, serverEphemeral = newTestChallenge(t, WithChallengeLifetime(time.Minute), WithClock(clock))
+	proofs, err = key.GenerateProofs(serverEphemeral, 0)
+	if err != nil {
+		t.Fatal("Expected no error while generating proofs, have ", err)
+	}
+	now = now.Add(59 * time.Second)
+	if _, err := server.VerifyProofs(proofs.ClientEphemeral, proofs.ClientProof); err != nil {
+		t.Fatal("Expected no error within the challenge lifetime, have ", err)
+	}
+}
+
+func TestServerChallengeLifetimeState(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	clock := func() time.Time { return now }
+
+	key, server, serverEphemeral := newTestChallenge(t, WithChallengeLifetime(time.Minute), WithClock(clock))
+	state, err := server.MarshalBinary()
+	if err != nil {
+		t.Fatal("Expected no error while serializing server, have ", err)
+	}
+	proofs, err := key.GenerateProofs(serverEphemeral, 0)
+	if err != nil {
+		t.Fatal("Expected no error while generating proofs, have ", err)
+	}
+
+	now = now.Add(time.Minute)
+	restored, err := UnmarshalServer(state, WithClock(clock))
+	if err != nil {
+		t.Fatal("Expected no error while restoring server, have ", err)
+	}
+	if _, err := restored.VerifyProofs(proofs.ClientEphemeral, proofs.ClientProof); !errors.Is(err, ErrChallengeExpired) {
+		t.Fatal("Expected the restored server to expire but have ", err)
+	}
+
+}
+
+func TestServerSingleUse(t *testing.T) {
+	key, server, serverEphemeral := newTestChallenge(t)
+	proofs, err := key.GenerateProofs(serverEphemeral, 0)
+	if err != nil {
+		t.Fatal("Expected no error while generating proofs, have ", err)
+	}
+
+	wrongProof := append([]byte{}, proofs.ClientProof...)
+	wrongProof[0] ^= 1
+	if _, err := server.VerifyProofs(proofs.ClientEphemeral, wrongProof); !errors.Is(err, ErrInvalidClientProof) {
+		t.Fatal("Expected the ErrInvalidClientProof but have ", err)
+	}
+	if server.serverSecret != nil || server.sharedSession != nil {
+		t.Fatal("Expected the server secrets to be wiped after a failed attempt")
+	}
+	if _, err := server.VerifyProofs(proofs.ClientEphemeral, proofs.ClientProof); !errors.Is(err, ErrChallengeAlreadyUsed) {
+		t.Fatal("Expected the ErrChallengeAlreadyUsed on a second attempt but have ", err)
+	}
+	if _, err := server.GenerateChallenge(); !errors.Is(err, ErrChallengeAlreadyUsed) {
+		t.Fatal("Expected the ErrChallengeAlreadyUsed while generating a new challenge but have ", err)
+	}
+	if _, err := server.MarshalBinary(); !errors.Is(err, ErrChallengeAlreadyUsed) {
+		t.Fatal("Expected the ErrChallengeAlreadyUsed while serializing a used server but have ", err)
+	}
+
+	key, server, serverEphemeral = newTestChallenge(t)
+	proofs, err = key.GenerateProofs(serverEphemeral, 0)
+	if err != nil {
+		t.Fatal("Expected no error while generating proofs, have ", err)
+	}
+	if _, err := server.VerifyProofs(proofs.ClientEphemeral, proofs.ClientProof); err != nil {
+		t.Fatal("Expected no error while verifying client proof, have ", err)
+	}
+	if server.serverSecret != nil {
+		t.Fatal("Expected the server secret to be wiped after a successful attempt")
+	}
+	if _, err := server.GetSharedSession(); err != nil {
+		t.Fatal("Expected the shared session to be kept after a successful attempt, have ", err)
+	}
+	if _, err := server.VerifyProofs(proofs.ClientEphemeral, proofs.ClientProof); !errors.Is(err, ErrChallengeAlreadyUsed) {
+		t.Fatal("Expected the ErrChallengeAlreadyUsed when replaying the proofs but have ", err)
+	}
+}
+
+func TestWipeNat(t *testing.T) {
+	nat := toNat([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	wipeNat(nat)
+	if _, isZero, _ := nat.Cmp(newNat(0)); isZero != 1 {
+		t.Fatal("Expected the wiped number to be zero")
+	}
+}
@@ -0,0 +1,148 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+import (
+	"crypto/sha512"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// Labels of the keys derived from the shared session.
+const (
+	SessionKeyEncryption = "encryption"
+	SessionKeyMAC        = "mac"
+	SessionKeyResumption = "resumption"
+)
+
+// SessionKeySize is the size in bytes of the keys returned by DeriveSessionKeys.
+const SessionKeySize = 32
+
+// maxSessionKeySize is the largest output HKDF-SHA512 can produce.
+const maxSessionKeySize = 255 * sha512.Size
+
+// SessionKeys holds the keys derived from a concluded SRP exchange.
+type SessionKeys struct {
+	EncryptionKey, MACKey, ResumptionSecret []byte
+}
+
+// deriveSessionKey expands the shared session with HKDF-SHA512 into a key of
+// the given length. The label is bound to the output, so distinct labels yield
+// independent keys.
+func deriveSessionKey(sharedSession []byte, label string, length int) ([]byte, error) {
+	if label == "" {
+		return nil, ErrInvalidSessionKeyLabel
+	}
+	if length <= 0 || length > maxSessionKeySize {
+		return nil, ErrInvalidSessionKeyLength
+	}
+
+	key := make([]byte, length)
+	reader := hkdf.New(sha512.New, sharedSession, nil, []byte("go-srp session key: "+label))
+	if _, err := io.ReadFull(reader, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// deriveSessionKeys derives the default set of session keys.
+func deriveSessionKeys(sharedSession []byte) (*SessionKeys, error) {
+	encryptionKey, err := deriveSessionKey(sharedSession, SessionKeyEncryption, SessionKeySize)
+	if err != nil {
+		return nil, err
+	}
+	macKey, err := deriveSessionKey(sharedSession, SessionKeyMAC, SessionKeySize)
+	if err != nil {
+		return nil, err
+	}
+	resumptionSecret, err := deriveSessionKey(sharedSession, SessionKeyResumption, SessionKeySize)
+	if err != nil {
+		return nil, err
+	}
+	return &SessionKeys{
+		EncryptionKey:    encryptionKey,
+		MACKey:           macKey,
+		ResumptionSecret: resumptionSecret,
+	}, nil
+}
+
+// DeriveSessionKey derives a labelled key of the given length from the shared
+// session. The server proof must have been verified first.
+func (p *Proofs) DeriveSessionKey(label string, length int) ([]byte, error) {
+	sharedSession, err := p.GetSharedSession()
+	if err != nil {
+		return nil, err
+	}
+	return deriveSessionKey(sharedSession, label, length)
+}
+
+// DeriveSessionKeys derives the encryption key, MAC key and resumption secret
+// from the shared session. The server proof must have been verified first.
+func (p *Proofs) DeriveSessionKeys() (*SessionKeys, error) {
+	sharedSession, err := p.GetSharedSession()
+	if err != nil {
+		return nil, err
+	}
+	return deriveSessionKeys(sharedSession)
+}
+
+// DeriveSessionKey derives a labelled key of the given length from the shared
+// session. The exchange must have concluded in valid state.
+func (s *Server) DeriveSessionKey(label string, length int) ([]byte, error) {
+	sharedSession, err := s.GetSharedSession()
+	if err != nil {
+		return nil, err
+	}
+	return deriveSessionKey(sharedSession, label, length)
+}
+
+// DeriveSessionKeys derives the encryption key, MAC key and resumption secret
+// from the shared session. The exchange must have concluded in valid state.
+func (s *Server) DeriveSessionKeys() (*SessionKeys, error) {
+	sharedSession, err := s.GetSharedSession()
+	if err != nil {
+		return nil, err
+	}
+	return deriveSessionKeys(sharedSession)
+}
+
+// DeriveSessionKey derives a labelled key of the given length from the shared
+// session. The exchange must have concluded in valid state.
+func (c *Client) DeriveSessionKey(label string, length int) ([]byte, error) {
+	sharedSession, err := c.SharedSession()
+	if err != nil {
+		return nil, err
+	}
+	return deriveSessionKey(sharedSession, label, length)
+}
+
+// DeriveSessionKeys derives the encryption key, MAC key and resumption secret
+// from the shared session. The exchange must have concluded in valid state.
+func (c *Client) DeriveSessionKeys() (*SessionKeys, error) {
+	sharedSession, err := c.SharedSession()
+	if err != nil {
+		return nil, err
+	}
+	return deriveSessionKeys(sharedSession)
+}
@@ -0,0 +1,112 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestDeriveSessionKeys(t *testing.T) {
+	var bits = 2048
+	var password = []byte("abc123")
+
+	rawSalt, err := RandomBytes(10)
+	if err != nil {
+		t.Fatal("Expected no error while generating raw salt, have ", err)
+	}
+	verifierAuth, err := NewAuthForVerifier(password, testModulusClearSign, rawSalt)
+	if err != nil {
+		t.Fatal("Expected no error while creating auth for verifier, have ", err)
+	}
+	verifier, err := verifierAuth.GenerateVerifier(bits)
+	if err != nil {
+		t.Fatal("Expected no error while generating verifier, have ", err)
+	}
+	server, err := NewServerFromSigned(testModulusClearSign, verifier, bits)
+	if err != nil {
+		t.Fatal("Expected no error while creating server, have ", err)
+	}
+	challenge, err := server.GenerateChallenge()
+	if err != nil {
+		t.Fatal("Expected no error while generating challenge, have ", err)
+	}
+	auth, err := NewAuth(4, "Test", password, base64.StdEncoding.EncodeToString(rawSalt), testModulusClearSign, base64.StdEncoding.EncodeToString(challenge))
+	if err != nil {
+		t.Fatal("Expected no error while creating auth, have ", err)
+	}
+	proofs, err := auth.GenerateProofs(bits)
+	if err != nil {
+		t.Fatal("Expected no error while generating client proofs, have ", err)
+	}
+
+	if _, err := proofs.DeriveSessionKeys(); !errors.Is(err, ErrNotCompleted) {
+		t.Fatal("Expected the ErrNotCompleted but have ", err)
+	}
+
+	serverProof, err := server.VerifyProofs(proofs.ClientEphemeral, proofs.ClientProof)
+	if err != nil {
+		t.Fatal("Expected no error while verifying client proofs, have ", err)
+	}
+	if err := proofs.VerifyServerProof(serverProof); err != nil {
+		t.Fatal("Expected no error while verifying server proof, have ", err)
+	}
+
+	clientKeys, err := proofs.DeriveSessionKeys()
+	if err != nil {
+		t.Fatal("Expected no error while deriving client keys, have ", err)
+	}
+	serverKeys, err := server.DeriveSessionKeys()
+	if err != nil {
+		t.Fatal("Expected no error while deriving server keys, have ", err)
+	}
+
+	if !bytes.Equal(clientKeys.EncryptionKey, serverKeys.EncryptionKey) ||
+		!bytes.Equal(clientKeys.MACKey, serverKeys.MACKey) ||
+		!bytes.Equal(clientKeys.ResumptionSecret, serverKeys.ResumptionSecret) {
+		t.Fatal("Expected client and server to derive the same session keys")
+	}
+	if len(clientKeys.EncryptionKey) != SessionKeySize {
+		t.Fatalf("Expected session key of %d bytes, have %d", SessionKeySize, len(clientKeys.EncryptionKey))
+	}
+	if bytes.Equal(clientKeys.EncryptionKey, clientKeys.MACKey) {
+		t.Fatal("Expected distinct labels to derive distinct keys")
+	}
+
+	custom, err := proofs.DeriveSessionKey("custom", 64)
+	if err != nil {
+		t.Fatal("Expected no error while deriving custom key, have ", err)
+	}
+	if len(custom) != 64 {
+		t.Fatalf("Expected custom key of 64 bytes, have %d", len(custom))
+	}
+
+	if _, err := server.DeriveSessionKey("", 32); !errors.Is(err, ErrInvalidSessionKeyLabel) {
+		t.Fatal("Expected the ErrInvalidSessionKeyLabel but have ", err)
+	}
+	if _, err := server.DeriveSessionKey("custom", 0); !errors.Is(err, ErrInvalidSessionKeyLength) {
+		t.Fatal("Expected the ErrInvalidSessionKeyLength but have ", err)
+	}
+}
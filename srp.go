@@ -24,8 +24,10 @@ package srp
 
 import (
 	"bytes"
+	"context"
+	"crypto/subtle"
 	"encoding/base64"
-	"errors"
+	"io"
 	"math/big"
 
 	"crypto/rand"
@@ -36,23 +38,52 @@ import (
 )
 
 var (
-	// ErrDataAfterModulus found extra data after decode the modulus
-	ErrDataAfterModulus = errors.New("pm-srp: extra data after modulus")
-
-	// ErrInvalidSignature invalid modulus signature
-	ErrInvalidSignature = errors.New("pm-srp: invalid modulus signature")
-
+	// RandReader is the default source of randomness, used by the instances
+	// created without the WithRandReader option.
 	RandReader = rand.Reader
 )
 
-// Store random reader in a variable to be able to overwrite it in tests
-
 // Proofs Srp Proofs object. Changed SrpProofs to Proofs because the name will be used as srp.SrpProofs by other packages and as SrpSrpProofs on mobile
 // ClientProof []byte  client proof
 // ClientEphemeral []byte  calculated from
 // ExpectedServerProof []byte
 type Proofs struct {
 	ClientProof, ClientEphemeral, ExpectedServerProof, sharedSession []byte
+	completed                                                        bool
+}
+
+// VerifyServerProof compares the proof sent by the server with the expected one
+// in constant time. If they match, the exchange is concluded in valid state and
+// the shared session becomes available. Otherwise the shared session is wiped.
+func (p *Proofs) VerifyServerProof(serverProof []byte) error {
+	if p.sharedSession == nil {
+		return ErrSharedSessionUnavailable
+	}
+	if len(serverProof) != len(p.ExpectedServerProof) {
+		clear(p.sharedSession)
+		p.sharedSession = nil
+		return &InputError{Input: "server proof", Err: ErrInvalidLength}
+	}
+	if subtle.ConstantTimeCompare(p.ExpectedServerProof, serverProof) == 0 {
+		clear(p.sharedSession)
+		p.sharedSession = nil
+		return ErrInvalidServerProof
+	}
+	p.completed = true
+	return nil
+}
+
+// IsCompleted returns true if the server proof has been verified.
+func (p *Proofs) IsCompleted() bool {
+	return p.completed
+}
+
+// GetSharedSession returns the shared secret as byte if the server proof has been verified.
+func (p *Proofs) GetSharedSession() ([]byte, error) {
+	if !p.IsCompleted() {
+		return nil, ErrNotCompleted
+	}
+	return p.sharedSession, nil
 }
 
 // Auth stores byte data for the calculation of SRP proofs.
@@ -61,6 +92,16 @@ type Proofs struct {
 type Auth struct {
 	Modulus, ServerEphemeral, HashedPassword []byte
 	Version                                  int
+	randReader                               io.Reader
+	group                                    *Group
+	username                                 string
+	salt                                     []byte
+}
+
+// groupFor returns the group shared through the WithGroup option if it matches
+// the modulus and bit length of the auth, or validates a new one otherwise.
+func (s *Auth) groupFor(bitLength int) (*Group, error) {
+	return sharedGroup(s.group, s.Modulus, bitLength)
 }
 
 // Amored pubkey for modulus verification
@@ -77,7 +118,7 @@ func readClearSignedMessage(signedMessage string) (string, error) {
 
 	modulusKeyring, err := openpgp.ReadArmoredKeyRing(bytes.NewReader([]byte(modulusPubkey)))
 	if err != nil {
-		return "", errors.New("pm-srp: can not read modulus pubkey")
+		return "", ErrInvalidModulusKey
 	}
 
 	_, err = openpgp.CheckDetachedSignature(modulusKeyring, bytes.NewReader(modulusBlock.Bytes), modulusBlock.ArmoredSignature.Body, nil)
@@ -109,43 +150,17 @@ func GetModulusKey() string {
 // Warnings:
 //	 - Be careful! Poos can hurt.
 func NewAuth(version int, username string, password []byte, b64salt, signedModulus, serverEphemeral string) (auth *Auth, err error) {
-	data := &Auth{}
-
-	// Modulus
-	var modulus string
-	modulus, err = readClearSignedMessage(signedModulus)
-	if err != nil {
-		return
-	}
-	data.Modulus, err = base64.StdEncoding.DecodeString(modulus)
-	if err != nil {
-		return
-	}
-
-	// Password
-	var decodedSalt []byte
-	if version >= 3 {
-		decodedSalt, err = base64.StdEncoding.DecodeString(b64salt)
-		if err != nil {
-			return
-		}
-	}
-	data.HashedPassword, err = HashPassword(version, password, username, decodedSalt, data.Modulus)
-	if err != nil {
-		return
-	}
+	return NewAuthWithOptions(version, username, password, b64salt, signedModulus, serverEphemeral)
+}
 
-	// Server ephermeral
-	data.ServerEphemeral, err = base64.StdEncoding.DecodeString(serverEphemeral)
+// NewAuthWithOptions is NewAuth with options, such as WithRandReader or WithGroup.
+func NewAuthWithOptions(version int, username string, password []byte, b64salt, signedModulus, serverEphemeral string, opts ...Option) (auth *Auth, err error) {
+	key, err := NewPasswordKey(version, username, password, b64salt, signedModulus, opts...)
 	if err != nil {
 		return
 	}
 
-	// Authentication version
-	data.Version = version
-
-	auth = data
-	return
+	return key.NewAuth(serverEphemeral)
 }
 
 // NewAuthForVerifier Creates new Auth from strings input. Salt and server ephemeral are in
@@ -165,7 +180,14 @@ func NewAuth(version int, username string, password []byte, b64salt, signedModul
 // Warnings:
 //	 - none.
 func NewAuthForVerifier(password []byte, signedModulus string, rawSalt []byte) (auth *Auth, err error) {
-	data := &Auth{}
+	return NewAuthForVerifierWithOptions(password, signedModulus, rawSalt)
+}
+
+// NewAuthForVerifierWithOptions is NewAuthForVerifier with options, such as
+// WithRandReader or WithGroup.
+func NewAuthForVerifierWithOptions(password []byte, signedModulus string, rawSalt []byte, opts ...Option) (auth *Auth, err error) {
+	options := newOptions(opts)
+	data := &Auth{randReader: options.randReader, group: options.group}
 
 	// Modulus
 	var modulus string
@@ -179,7 +201,7 @@ func NewAuthForVerifier(password []byte, signedModulus string, rawSalt []byte) (
 	}
 
 	// hash version is 4
-	data.HashedPassword, err = hashPasswordVersion3(password, rawSalt, data.Modulus)
+	data.HashedPassword, err = suiteFor(options.group, data.Modulus).hashPassword(4, password, "", rawSalt, data.Modulus)
 	if err != nil {
 		return
 	}
@@ -235,26 +257,26 @@ func fromNat(bitLength int, nat *saferith.Nat) []byte {
 	return reversed
 }
 
-func computeMultiplier(generator, modulus *big.Int, bitLength int) (*saferith.Nat, error) {
+func computeMultiplier(expander Expander, generator, modulus *big.Int, bitLength int) (*saferith.Nat, error) {
 	modulusMinusOne := big.NewInt(0).Sub(modulus, big.NewInt(1))
-	multiplier := toInt(expandHash(append(fromInt(bitLength, generator), fromInt(bitLength, modulus)...)))
+	multiplier := toInt(expander.Expand(append(fromInt(bitLength, generator), fromInt(bitLength, modulus)...), bitLength/8))
 	multiplier = multiplier.Mod(multiplier, modulus)
 
 	if multiplier.Cmp(big.NewInt(1)) <= 0 || multiplier.Cmp(modulusMinusOne) >= 0 {
-		return nil, errors.New("pm-srp: SRP multiplier is out of bounds")
+		return nil, ErrMultiplierOutOfBounds
 	}
 
 	return new(saferith.Nat).SetBig(multiplier, bitLength), nil
 }
 
-func checkParams(bitLength int, ephemeral, generator, modulus *big.Int) error {
-
+// checkModulus validates the group parameters, see NewGroup.
+func checkModulus(bitLength int, generator, modulus *big.Int) error {
 	if !generator.IsInt64() || generator.Int64() != 2 {
-		return errors.New("go-srp: SRP generator must always be 2")
+		return ErrUnsupportedGenerator
 	}
 
 	if modulus.BitLen() != bitLength {
-		return errors.New("go-srp: SRP modulus has incorrect size")
+		return ErrModulusSize
 	}
 
 	if modulus.Bit(0) != 1 || modulus.Bit(1) != 1 || modulus.Bit(2) != 0 {
@@ -264,20 +286,17 @@ func checkParams(bitLength int, ephemeral, generator, modulus *big.Int) error {
 		// *not* be a square. In addition, since N should be prime, it
 		// must not be even, and since (N-1)/2 should be prime, N must
 		// not be 1 mod 4. This leaves 3 mod 8 as the only option.
-		return errors.New("go-srp: SRP modulus is not 3 mod 8")
+		return ErrModulusNot3Mod8
 	}
 
 	modulusMinusOne := big.NewInt(0).Sub(modulus, big.NewInt(1))
-	if ephemeral.Cmp(big.NewInt(1)) <= 0 || ephemeral.Cmp(modulusMinusOne) >= 0 {
-		return errors.New("go-srp: SRP server ephemeral is out of bounds")
-	}
 
 	// halfModulus is (N-1)/2. We've already checked that N is odd.
 	halfModulus := big.NewInt(0).Rsh(modulus, 1)
 
 	// Check safe primality
 	if !halfModulus.ProbablyPrime(10) {
-		return errors.New("pm-srp: SRP modulus is not a safe prime")
+		return ErrModulusNotSafePrime
 	}
 
 	// Check primality using the Lucas primality test. This requires a
@@ -289,23 +308,19 @@ func checkParams(bitLength int, ephemeral, generator, modulus *big.Int) error {
 	// and doubles as a test / guarantee that 2 is a generator of the whole group
 	// (and not a square).
 	if big.NewInt(0).Exp(generator, halfModulus, modulus).Cmp(modulusMinusOne) != 0 {
-		return errors.New("pm-srp: SRP modulus is not prime")
+		return ErrModulusNotPrime
 	}
 
 	return nil
 }
 
-func generateClientEphemeral(
-	bitLength int,
-	modulusInt, modulusMinusOneInt *big.Int,
-	modulusMinusOneNat *saferith.Nat,
-	modulus *saferith.Modulus,
-) (secret *saferith.Nat, ephemeral []byte, err error) {
+func generateClientEphemeral(randReader io.Reader, group *Group) (secret *saferith.Nat, ephemeral []byte, err error) {
 	var secretInt *big.Int
 	var secretBytes []byte
+	bitLength := group.bitLength
 	lowerBoundNat := newNat(uint64(bitLength * 2))
 	for {
-		secretInt, err = rand.Int(RandReader, modulusMinusOneInt)
+		secretInt, err = rand.Int(randReader, group.modulusMinusOneInt)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -315,23 +330,27 @@ func generateClientEphemeral(
 		// Prevent g^a from being smaller than the modulus
 		// and a to be >= than N-1
 		notTooSmall, _, _ := secret.Cmp(lowerBoundNat)
-		_, _, notTooLarge := secret.Cmp(modulusMinusOneNat)
+		_, _, notTooLarge := secret.Cmp(group.modulusMinusOneNat)
 		if notTooSmall == 1 && notTooLarge == 1 {
 			break
 		}
 	}
-	ephemeralNat := new(saferith.Nat).Exp(newNat(2), secret, modulus)
-	ephemeral = fromNat(bitLength, ephemeralNat)
+	ephemeralNat := new(saferith.Nat).Exp(group.generatorNat, secret, group.modulus)
+	ephemeral = group.fromNat(ephemeralNat)
 	return secret, ephemeral, nil
 }
 
-func computeScrambleParam(clientEphemeralBytes, serverEphemeralBytes []byte) *saferith.Nat {
+func computeScrambleParam(expander Expander, bitLength int, clientEphemeralBytes, serverEphemeralBytes []byte) *saferith.Nat {
 	return toNat(
-		expandHash(
-			append(
-				clientEphemeralBytes,
-				serverEphemeralBytes...,
+		expander.Expand(
+			bytes.Join(
+				[][]byte{
+					clientEphemeralBytes,
+					serverEphemeralBytes,
+				},
+				[]byte{},
 			),
+			bitLength/8,
 		),
 	)
 }
@@ -366,36 +385,8 @@ func computeExponentClientSide(bitLength int, scramblingParam, hashedPassword, c
 	)
 }
 
-func computeSharedSecretClientSide(
-	bitLength int,
-	hashedPassword, generator, serverEphemeral, multiplier, modulusMinusOneNat, clientSecret, scramblingParam *saferith.Nat,
-	modulus *saferith.Modulus,
-) []byte {
-	base := computeBaseClientSide(
-		hashedPassword,
-		generator,
-		serverEphemeral,
-		multiplier,
-		modulus,
-	)
-	modulusMinusOne := saferith.ModulusFromNat(modulusMinusOneNat)
-	exponent := computeExponentClientSide(
-		bitLength,
-		scramblingParam,
-		hashedPassword,
-		clientSecret,
-		modulusMinusOne,
-	)
-	sharedSession := new(saferith.Nat).Exp(
-		base,
-		exponent,
-		modulus,
-	)
-	return fromNat(bitLength, sharedSession)
-}
-
-func computeClientProof(clientEphemeral, serverEphemeral, sharedSecret []byte) []byte {
-	return expandHash(
+func computeClientProof(expander Expander, bitLength int, clientEphemeral, serverEphemeral, sharedSecret []byte) []byte {
+	return expander.Expand(
 		bytes.Join(
 			[][]byte{
 				clientEphemeral,
@@ -404,11 +395,12 @@ func computeClientProof(clientEphemeral, serverEphemeral, sharedSecret []byte) [
 			},
 			[]byte{},
 		),
+		bitLength/8,
 	)
 }
 
-func computeServerProof(clientEphemeral, clientProof, sharedSecret []byte) []byte {
-	return expandHash(
+func computeServerProof(expander Expander, bitLength int, clientEphemeral, clientProof, sharedSecret []byte) []byte {
+	return expander.Expand(
 		bytes.Join(
 			[][]byte{
 				clientEphemeral,
@@ -417,69 +409,76 @@ func computeServerProof(clientEphemeral, clientProof, sharedSecret []byte) []byt
 			},
 			[]byte{},
 		),
+		bitLength/8,
 	)
 }
 
-// GenerateProofs calculates SPR proofs.
+// GenerateProofs calculates SPR proofs. A bitLength of 0 infers the size from
+// the modulus, any other value must match it.
 func (s *Auth) GenerateProofs(bitLength int) (*Proofs, error) {
-	serverEphemeralInt := toInt(s.ServerEphemeral)
-	generatorInt := big.NewInt(2)
-	modulusInt := toInt(s.Modulus)
-	modulusMinusOneInt := big.NewInt(0).Sub(modulusInt, big.NewInt(1))
-	err := checkParams(
-		bitLength,
-		serverEphemeralInt,
-		generatorInt,
-		modulusInt,
-	)
+	return s.generateProofs(context.Background(), bitLength)
+}
+
+// generateProofs calculates SRP proofs, checking ctx before the modulus
+// validation and each exponentiation.
+func (s *Auth) generateProofs(ctx context.Context, bitLength int) (*Proofs, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	group, err := s.groupFor(bitLength)
 	if err != nil {
 		return nil, err
 	}
 
-	modulus := toModulus(s.Modulus)
-	modulusMinusOneNat := new(saferith.Nat).SetBig(modulusMinusOneInt, bitLength)
+	if err := group.checkEphemeral("server ephemeral", s.ServerEphemeral); err != nil {
+		return nil, err
+	}
+	serverEphemeralBytes := s.ServerEphemeral
+
 	var clientSecret, scramblingParam *saferith.Nat
 	var clientEphemeralBytes []byte
 	for {
-		clientSecret, clientEphemeralBytes, err = generateClientEphemeral(
-			bitLength,
-			modulusInt, modulusMinusOneInt,
-			modulusMinusOneNat,
-			modulus,
-		)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		clientSecret, clientEphemeralBytes, err = generateClientEphemeral(randReaderOrDefault(s.randReader), group)
 		if err != nil {
 			return nil, err
 		}
-		scramblingParam = computeScrambleParam(clientEphemeralBytes, s.ServerEphemeral)
+		scramblingParam = group.computeScrambleParam(clientEphemeralBytes, serverEphemeralBytes)
 		if _, equal, _ := scramblingParam.Cmp(newNat(0)); equal != 1 { // Very likely
 			break
 		}
 	}
 
-	multiplierNat, err := computeMultiplier(generatorInt, modulusInt, bitLength)
-	if err != nil {
+	hashedPasswordNat := group.toNat(s.HashedPassword)
+	serverEphemeralNat := group.toNat(serverEphemeralBytes)
+
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
-
-	hashedPasswordNat := toNat(s.HashedPassword)
-	generatorNat := newNat(2)
-	serverEphemeralNat := toNat(s.ServerEphemeral)
-
-	sharedSecret := computeSharedSecretClientSide(
-		bitLength,
+	base := computeBaseClientSide(
 		hashedPasswordNat,
-		generatorNat,
+		group.generatorNat,
 		serverEphemeralNat,
-		multiplierNat,
-		modulusMinusOneNat,
-		clientSecret,
+		group.multiplier,
+		group.modulus,
+	)
+	exponent := computeExponentClientSide(
+		bitLength,
 		scramblingParam,
-		modulus,
+		hashedPasswordNat,
+		clientSecret,
+		group.modulusMinusOne,
 	)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	sharedSecret := group.fromNat(new(saferith.Nat).Exp(base, exponent, group.modulus))
 
-	clientProof := computeClientProof(clientEphemeralBytes, s.ServerEphemeral, sharedSecret)
+	clientProof := group.computeClientProof(s.username, s.salt, clientEphemeralBytes, serverEphemeralBytes, sharedSecret)
 
-	serverProof := computeServerProof(clientEphemeralBytes, clientProof, sharedSecret)
+	serverProof := group.computeServerProof(clientEphemeralBytes, clientProof, sharedSecret)
 
 	proofs := &Proofs{
 		ClientEphemeral:     clientEphemeralBytes,
@@ -492,11 +491,13 @@ func (s *Auth) GenerateProofs(bitLength int) (*Proofs, error) {
 
 // GenerateVerifier verifier for update pwds and create accounts
 func (s *Auth) GenerateVerifier(bitLength int) ([]byte, error) {
-	modulus := toModulus(s.Modulus)
-	generator := newNat(2)
-	hashedPassword := toNat(s.HashedPassword)
-	calModPow := new(saferith.Nat).SetUint64(0).Exp(generator, hashedPassword, modulus)
-	return fromNat(bitLength, calModPow), nil
+	group, err := s.groupFor(bitLength)
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword := group.toNat(s.HashedPassword)
+	calModPow := new(saferith.Nat).SetUint64(0).Exp(group.generatorNat, hashedPassword, group.modulus)
+	return group.fromNat(calModPow), nil
 }
 
 func RandomBits(bits int) ([]byte, error) {
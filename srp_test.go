@@ -24,7 +24,6 @@ package srp
 
 import (
 	"bytes"
-	pmrand "crypto/rand"
 	"encoding/base64"
 	"encoding/hex"
 	"math/rand"
@@ -52,12 +51,6 @@ kvNM7qIK
 -----END PGP SIGNATURE-----`
 )
 
-func init() {
-	// Only for tests, replace the default random reader by something that always
-	// return the same thing
-	RandReader = rand.New(rand.NewSource(42))
-}
-
 func TestReadClearSigned(t *testing.T) {
 	cleartext, err := readClearSignedMessage(testModulusClearSign)
 	if err != nil {
@@ -84,7 +77,8 @@ func TestReadClearSigned(t *testing.T) {
 }
 
 func TestSRPauth(t *testing.T) {
-	srp, err := NewAuth(4, "jakubqa", []byte("abc123"), "yKlc5/CvObfoiw==", testModulusClearSign, testServerEphemeral)
+	// Replace the random reader by something that always return the same thing
+	srp, err := NewAuthWithOptions(4, "jakubqa", []byte("abc123"), "yKlc5/CvObfoiw==", testModulusClearSign, testServerEphemeral, WithRandReader(rand.New(rand.NewSource(42))))
 	if err != nil {
 		t.Fatal("Expected no error but have ", err)
 	}
@@ -178,8 +172,6 @@ func TestNewAuth(t *testing.T) {
 
 // TestE2EFlow performs a test with the client and server using real random data.
 func TestE2EFlow(t *testing.T) {
-	RandReader = pmrand.Reader
-
 	var bits = 2048
 	var password = []byte("Password\nabc!!~~ä\r\n")
 
@@ -241,21 +233,67 @@ func TestE2EFlow(t *testing.T) {
 		)
 	}
 
+	if _, err := proofs.GetSharedSession(); err != ErrNotCompleted {
+		t.Fatal("Expected the ErrNotCompleted but have ", err)
+	}
+
+	if err := proofs.VerifyServerProof(serverProof); err != nil {
+		t.Fatal("Expected no error while verifying server proof, have ", err)
+	}
+
+	if !proofs.IsCompleted() {
+		t.Fatal("Expected client side of the SRP exchange to be completed")
+	}
+
 	sharedSession, err := server.GetSharedSession()
 	if err != nil {
 		t.Fatal("Expected no error while getting shared session secret, have ", err)
 	}
 
-	if bytes.Compare(proofs.sharedSession, sharedSession) != 0 {
+	clientSharedSession, err := proofs.GetSharedSession()
+	if err != nil {
+		t.Fatal("Expected no error while getting client shared session secret, have ", err)
+	}
+
+	if bytes.Compare(clientSharedSession, sharedSession) != 0 {
 		t.Fatalf("Expected server proof\n\t'%s'\nbut have\n\t'%s'",
-			hex.EncodeToString(proofs.sharedSession),
+			hex.EncodeToString(clientSharedSession),
 			hex.EncodeToString(sharedSession),
 		)
 	}
 }
 
+func TestVerifyServerProof(t *testing.T) {
+	srp, err := NewAuth(4, "jakubqa", []byte("abc123"), "yKlc5/CvObfoiw==", testModulusClearSign, testServerEphemeral)
+	if err != nil {
+		t.Fatal("Expected no error but have ", err)
+	}
+
+	proofs, err := srp.GenerateProofs(2048)
+	if err != nil {
+		t.Fatal("Expected no error but have ", err)
+	}
+
+	wrongProof := append([]byte{}, proofs.ExpectedServerProof...)
+	wrongProof[0] ^= 0x01
+	if err := proofs.VerifyServerProof(wrongProof); err != ErrInvalidServerProof {
+		t.Fatal("Expected the ErrInvalidServerProof but have ", err)
+	}
+
+	if proofs.IsCompleted() {
+		t.Fatal("Expected SRP exchange not to be completed after an invalid server proof")
+	}
+
+	if err := proofs.VerifyServerProof(proofs.ExpectedServerProof); err != ErrSharedSessionUnavailable {
+		t.Fatal("Expected the ErrSharedSessionUnavailable but have ", err)
+	}
+
+	if _, err := proofs.GetSharedSession(); err != ErrNotCompleted {
+		t.Fatal("Expected the ErrNotCompleted but have ", err)
+	}
+}
+
 func BenchmarkGenerateProofs(b *testing.B) {
-	RandReader = pmrand.Reader
 	srp, err := NewAuth(4, "jakubqa", []byte("abc123"), "yKlc5/CvObfoiw==", testModulusClearSign, testServerEphemeral)
 	if err != nil {
 		b.Fatal("Expected no error but have ", err)
@@ -270,7 +308,6 @@ func BenchmarkGenerateProofs(b *testing.B) {
 }
 
 func BenchmarkGenerateVerifier(b *testing.B) {
-	RandReader = pmrand.Reader
 	salt, err := base64.StdEncoding.DecodeString("yKlc5/CvObfoiw==")
 	if err != nil {
 		b.Fatal("Expected no error but have ", err)
@@ -0,0 +1,354 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+// Package srphttp serves the two steps of the SRP exchange over HTTP, with the
+// JSON bodies of the auth info and auth API calls.
+package srphttp
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+
+	srp "github.com/ProtonMail/go-srp"
+)
+
+// maxBodySize is the maximum size of a request body.
+const maxBodySize = 64 << 10
+
+// ErrAccountNotFound is returned by an AccountLookup for unknown usernames.
+var ErrAccountNotFound = errors.New("srphttp: account not found")
+
+// Account is the SRP record of a user.
+type Account struct {
+	Version       int
+	SignedModulus string
+	Salt          []byte
+	Verifier      []byte
+}
+
+// AccountLookup returns the SRP record of a user, or ErrAccountNotFound.
+type AccountLookup interface {
+	LookupAccount(ctx context.Context, username string) (*Account, error)
+}
+
+// AccountLookupFunc adapts an ordinary function to the AccountLookup interface.
+type AccountLookupFunc func(ctx context.Context, username string) (*Account, error)
+
+// LookupAccount calls f(ctx, username).
+func (f AccountLookupFunc) LookupAccount(ctx context.Context, username string) (*Account, error) {
+	return f(ctx, username)
+}
+
+// InfoRequest is the body of the auth info API call.
+type InfoRequest struct {
+	Username string `json:"Username"`
+}
+
+// AuthResponse is the response of the auth API call. UpgradeRequired is set
+// for accounts of a legacy auth version, which should send the verifier bundle
+// of srp.UpgradeVerifierBundle, see srp.Server.NeedsUpgrade.
+type AuthResponse struct {
+	ServerProof     string `json:"ServerProof"`
+	UpgradeRequired bool   `json:"UpgradeRequired,omitempty"`
+}
+
+// AuthenticatedFunc is called once the client proof is verified, before the
+// auth response is written. It can set headers, e.g. a session cookie derived
+// from the shared session of the server. An error aborts the response with an
+// internal server error.
+type AuthenticatedFunc func(w http.ResponseWriter, r *http.Request, server *srp.Server) error
+
+// Option sets an optional parameter of a Handler.
+type Option func(*Handler)
+
+// WithServerOptions sets the options of the SRP servers, such as
+// srp.WithRandReader.
+func WithServerOptions(opts ...srp.Option) Option {
+	return func(h *Handler) {
+		h.serverOpts = opts
+	}
+}
+
+// WithDecoys answers the info requests of unknown usernames with the decoy
+// record derived from serverKey for the signed modulus, see srp.DecoyRecord.
+// Unknown usernames then look like real ones and only fail at the auth step.
+func WithDecoys(serverKey []byte, signedModulus string) Option {
+	return func(h *Handler) {
+		h.decoyKey = serverKey
+		h.decoyModulus = signedModulus
+	}
+}
+
+// WithAuthenticated sets the function called after a successful auth.
+func WithAuthenticated(authenticated AuthenticatedFunc) Option {
+	return func(h *Handler) {
+		h.authenticated = authenticated
+	}
+}
+
+// WithObserver sets the observer notified of the steps of the exchange, see
+// srp.Observer. It is set on every SRP server, overriding an srp.WithObserver
+// of WithServerOptions, and also gets the auth requests of unknown sessions as
+// srp.RejectSessionNotFound.
+func WithObserver(observer srp.Observer) Option {
+	return func(h *Handler) {
+		h.observer = observer
+	}
+}
+
+// WithLimiter sets the limiter of the SRP servers, keyed by username, see
+// srp.Limiter. Info requests refused by the limiter get a 429 status with a
+// Retry-After header. Handlers use srp.NewDefaultLimiter unless this option is
+// set, and a nil limiter disables rate limiting.
+//
+// Keyed by username alone, anyone can lock an account out by sending info
+// requests for its username until its bucket is empty. WithLimiterKey limits
+// each client instead, at the cost of guesses spread over many clients.
+func WithLimiter(limiter srp.Limiter) Option {
+	return func(h *Handler) {
+		h.limiter = limiter
+	}
+}
+
+// LimiterKeyFunc returns the limiter key of an info request for the username,
+// e.g. the username and the IP address of the client.
+type LimiterKeyFunc func(r *http.Request, username string) string
+
+// WithLimiterKey sets the function computing the limiter keys instead of the
+// username, see srp.WithLimiterKey.
+func WithLimiterKey(limiterKey LimiterKeyFunc) Option {
+	return func(h *Handler) {
+		h.limiterKey = limiterKey
+	}
+}
+
+// Handler serves the auth info and auth endpoints. Challenges are kept in the
+// session store between the two calls.
+type Handler struct {
+	accounts      AccountLookup
+	sessions      srp.SessionStore
+	serverOpts    []srp.Option
+	authenticated AuthenticatedFunc
+	observer      srp.Observer
+	limiter       srp.Limiter
+	limiterKey    LimiterKeyFunc
+	decoyKey      []byte
+	decoyModulus  string
+
+	mu     sync.Mutex
+	groups map[string]*srp.Group // by signed modulus
+}
+
+// NewHandler creates a handler looking up the users in accounts and keeping the
+// challenges in sessions. Failed attempts are limited per username with
+// srp.NewDefaultLimiter, which trades a lockout of the account for a bounded
+// number of guesses, see WithLimiter.
+func NewHandler(accounts AccountLookup, sessions srp.SessionStore, opts ...Option) *Handler {
+	h := &Handler{
+		accounts: accounts,
+		sessions: sessions,
+		limiter:  srp.NewDefaultLimiter(),
+		groups:   make(map[string]*srp.Group),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Info returns the handler of the auth info endpoint. It takes an InfoRequest
+// and returns an srp.AuthInfo with a new challenge.
+func (h *Handler) Info() http.Handler {
+	return http.HandlerFunc(h.serveInfo)
+}
+
+// Auth returns the handler of the auth endpoint. It takes an srp.AuthRequest
+// and returns an AuthResponse with the server proof.
+func (h *Handler) Auth() http.Handler {
+	return http.HandlerFunc(h.serveAuth)
+}
+
+func (h *Handler) serveInfo(w http.ResponseWriter, r *http.Request) {
+	var req InfoRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if req.Username == "" {
+		writeError(w, http.StatusBadRequest)
+		return
+	}
+
+	account, err := h.lookupAccount(r.Context(), req.Username)
+	if errors.Is(err, ErrAccountNotFound) {
+		writeError(w, http.StatusNotFound)
+		return
+	} else if err != nil {
+		writeError(w, http.StatusInternalServerError)
+		return
+	}
+
+	group, err := h.group(account.SignedModulus)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError)
+		return
+	}
+	opts := append([]srp.Option{
+		srp.WithGroup(group),
+		srp.WithUsername(req.Username),
+		srp.WithSalt(account.Salt),
+		srp.WithVersion(account.Version),
+	}, h.serverOpts...)
+	if h.observer != nil {
+		opts = append(opts, srp.WithObserver(h.observer))
+	}
+	if h.limiter != nil {
+		opts = append(opts, srp.WithLimiter(h.limiter))
+		if h.limiterKey != nil {
+			opts = append(opts, srp.WithLimiterKey(h.limiterKey(r, req.Username)))
+		}
+	}
+	sessionID, serverEphemeral, err := srp.NewSession(h.sessions, group.Modulus(), account.Verifier, opts...)
+	var rateLimitErr *srp.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		w.Header().Set("Retry-After", strconv.FormatInt(int64(math.Ceil(rateLimitErr.RetryAfter.Seconds())), 10))
+		writeError(w, http.StatusTooManyRequests)
+		return
+	} else if err != nil {
+		writeError(w, http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, &srp.AuthInfo{
+		Version:         account.Version,
+		Modulus:         account.SignedModulus,
+		ServerEphemeral: base64.StdEncoding.EncodeToString(serverEphemeral),
+		Salt:            base64.StdEncoding.EncodeToString(account.Salt),
+		SRPSession:      sessionID,
+	})
+}
+
+func (h *Handler) serveAuth(w http.ResponseWriter, r *http.Request) {
+	var req srp.AuthRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	clientEphemeral, err := base64.StdEncoding.DecodeString(req.ClientEphemeral)
+	if err != nil {
+		writeError(w, http.StatusBadRequest)
+		return
+	}
+	clientProof, err := base64.StdEncoding.DecodeString(req.ClientProof)
+	if err != nil {
+		writeError(w, http.StatusBadRequest)
+		return
+	}
+
+	server, serverProof, err := srp.VerifySession(h.sessions, req.SRPSession, clientEphemeral, clientProof)
+	if errors.Is(err, srp.ErrSessionNotFound) && h.observer != nil {
+		h.observer.ProofRejected(srp.ServerEvent{Reason: srp.RejectSessionNotFound, Err: err})
+	}
+	if err != nil {
+		writeError(w, http.StatusUnauthorized)
+		return
+	}
+	if h.authenticated != nil {
+		if err := h.authenticated(w, r, server); err != nil {
+			writeError(w, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	writeJSON(w, &AuthResponse{
+		ServerProof:     base64.StdEncoding.EncodeToString(serverProof),
+		UpgradeRequired: server.NeedsUpgrade(),
+	})
+}
+
+// lookupAccount returns the account of the username, or its decoy record if the
+// username is unknown and decoys are enabled.
+func (h *Handler) lookupAccount(ctx context.Context, username string) (*Account, error) {
+	account, err := h.accounts.LookupAccount(ctx, username)
+	if !errors.Is(err, ErrAccountNotFound) || h.decoyKey == nil {
+		return account, err
+	}
+
+	group, err := h.group(h.decoyModulus)
+	if err != nil {
+		return nil, err
+	}
+	bundle, err := srp.DecoyRecord(username, h.decoyKey, group)
+	if err != nil {
+		return nil, err
+	}
+	return &Account{
+		Version:       bundle.Version,
+		SignedModulus: h.decoyModulus,
+		Salt:          bundle.Salt,
+		Verifier:      bundle.Verifier,
+	}, nil
+}
+
+// group returns the validated group of the signed modulus. Groups are cached,
+// as there are few moduli and validating them is expensive.
+func (h *Handler) group(signedModulus string) (*srp.Group, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if group, ok := h.groups[signedModulus]; ok {
+		return group, nil
+	}
+	group, err := srp.NewGroupFromSigned(signedModulus, 0)
+	if err != nil {
+		return nil, err
+	}
+	h.groups[signedModulus] = group
+	return group, nil
+}
+
+// decodeRequest decodes the JSON body of a POST request, or writes an error.
+func decodeRequest(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed)
+		return false
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int) {
+	http.Error(w, http.StatusText(code), code)
+}
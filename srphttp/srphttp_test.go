@@ -0,0 +1,327 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srphttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	srp "github.com/ProtonMail/go-srp"
+)
+
+const testModulusClearSign = `-----BEGIN PGP SIGNED MESSAGE-----
+Hash: SHA256
+
+W2z5HBi8RvsfYzZTS7qBaUxxPhsfHJFZpu3Kd6s1JafNrCCH9rfvPLrfuqocxWPgWDH2R8neK7PkNvjxto9TStuY5z7jAzWRvFWN9cQhAKkdWgy0JY6ywVn22+HFpF4cYesHrqFIKUPDMSSIlWjBVmEJZ/MusD44ZT29xcPrOqeZvwtCffKtGAIjLYPZIEbZKnDM1Dm3q2K/xS5h+xdhjnndhsrkwm9U9oyA2wxzSXFL+pdfj2fOdRwuR5nW0J2NFrq3kJjkRmpO/Genq1UW+TEknIWAb6VzJJJA244K/H8cnSx2+nSNZO3bbo6Ys228ruV9A8m6DhxmS+bihN3ttQ==
+-----BEGIN PGP SIGNATURE-----
+Version: ProtonMail
+Comment: https://protonmail.com
+
+wl4EARYIABAFAlwB1j0JEDUFhcTpUY8mAAD8CgEAnsFnF4cF0uSHKkXa1GIa
+GO86yMV4zDZEZcDSJo0fgr8A/AlupGN9EdHlsrZLmTA1vhIx+rOgxdEff28N
+kvNM7qIK
+=q6vu
+-----END PGP SIGNATURE-----`
+
+func newTestHandler(t *testing.T, opts ...Option) *Handler {
+	t.Helper()
+	bundle, err := srp.NewVerifierBundle([]byte("abc123"), testModulusClearSign)
+	if err != nil {
+		t.Fatal("Expected no error while generating verifier bundle, have ", err)
+	}
+	accounts := AccountLookupFunc(func(ctx context.Context, username string) (*Account, error) {
+		if username != "jakubqa" {
+			return nil, ErrAccountNotFound
+		}
+		return &Account{
+			Version:       bundle.Version,
+			SignedModulus: testModulusClearSign,
+			Salt:          bundle.Salt,
+			Verifier:      bundle.Verifier,
+		}, nil
+	})
+	return NewHandler(accounts, srp.NewMemorySessionStore(time.Minute, 0), opts...)
+}
+
+func post(t *testing.T, handler http.Handler, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal("Expected no error while encoding request, have ", err)
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data)))
+	return recorder
+}
+
+func TestHandler(t *testing.T) {
+	var authenticated string
+	handler := newTestHandler(t, WithAuthenticated(func(w http.ResponseWriter, r *http.Request, server *srp.Server) error {
+		authenticated = server.Username()
+		return nil
+	}))
+
+	response := post(t, handler.Info(), &InfoRequest{Username: "jakubqa"})
+	if response.Code != http.StatusOK {
+		t.Fatal("Expected the info request to succeed but have ", response.Code)
+	}
+	var info srp.AuthInfo
+	if err := json.Unmarshal(response.Body.Bytes(), &info); err != nil {
+		t.Fatal("Expected no error while decoding info, have ", err)
+	}
+
+	auth, err := srp.NewAuthFromInfo(&info, "jakubqa", []byte("abc123"))
+	if err != nil {
+		t.Fatal("Expected no error while creating auth, have ", err)
+	}
+	proofs, err := auth.GenerateProofs(0)
+	if err != nil {
+		t.Fatal("Expected no error while generating proofs, have ", err)
+	}
+
+	response = post(t, handler.Auth(), proofs.AuthRequest(info.SRPSession))
+	if response.Code != http.StatusOK {
+		t.Fatal("Expected the auth request to succeed but have ", response.Code)
+	}
+	var authResponse AuthResponse
+	if err := json.Unmarshal(response.Body.Bytes(), &authResponse); err != nil {
+		t.Fatal("Expected no error while decoding auth response, have ", err)
+	}
+	serverProof, err := base64.StdEncoding.DecodeString(authResponse.ServerProof)
+	if err != nil {
+		t.Fatal("Expected no error while decoding server proof, have ", err)
+	}
+	if err := proofs.VerifyServerProof(serverProof); err != nil {
+		t.Fatal("Expected no error while verifying server proof, have ", err)
+	}
+	if authResponse.UpgradeRequired {
+		t.Fatal("Expected no upgrade for the default version")
+	}
+	if authenticated != "jakubqa" {
+		t.Fatal("Expected the authenticated function to be called for jakubqa but have ", authenticated)
+	}
+
+	response = post(t, handler.Auth(), proofs.AuthRequest(info.SRPSession))
+	if response.Code != http.StatusUnauthorized {
+		t.Fatal("Expected a replayed auth request to be rejected but have ", response.Code)
+	}
+}
+
+func TestHandlerErrors(t *testing.T) {
+	handler := newTestHandler(t)
+
+	if response := post(t, handler.Info(), &InfoRequest{Username: "unknown"}); response.Code != http.StatusNotFound {
+		t.Fatal("Expected an unknown user to be not found but have ", response.Code)
+	}
+	if response := post(t, handler.Info(), &InfoRequest{}); response.Code != http.StatusBadRequest {
+		t.Fatal("Expected a missing username to be rejected but have ", response.Code)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.Info().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Fatal("Expected a GET request to be rejected but have ", recorder.Code)
+	}
+
+	response := post(t, handler.Auth(), &srp.AuthRequest{ClientEphemeral: "!", ClientProof: "", SRPSession: "session"})
+	if response.Code != http.StatusBadRequest {
+		t.Fatal("Expected an invalid ephemeral to be rejected but have ", response.Code)
+	}
+	response = post(t, handler.Auth(), &srp.AuthRequest{SRPSession: "unknown"})
+	if response.Code != http.StatusUnauthorized {
+		t.Fatal("Expected an unknown session to be rejected but have ", response.Code)
+	}
+}
+
+func TestHandlerDecoys(t *testing.T) {
+	handler := newTestHandler(t, WithDecoys([]byte("decoy server key"), testModulusClearSign))
+
+	var infos []srp.AuthInfo
+	for i := 0; i < 2; i++ {
+		response := post(t, handler.Info(), &InfoRequest{Username: "unknown"})
+		if response.Code != http.StatusOK {
+			t.Fatal("Expected the info request of an unknown user to succeed but have ", response.Code)
+		}
+		var info srp.AuthInfo
+		if err := json.Unmarshal(response.Body.Bytes(), &info); err != nil {
+			t.Fatal("Expected no error while decoding info, have ", err)
+		}
+		infos = append(infos, info)
+	}
+	if infos[0].Salt != infos[1].Salt || infos[0].Version != infos[1].Version || infos[0].Modulus != testModulusClearSign {
+		t.Fatal("Expected the decoy info of a username to be stable")
+	}
+
+	auth, err := srp.NewAuthFromInfo(&infos[1], "unknown", []byte("abc123"))
+	if err != nil {
+		t.Fatal("Expected no error while creating auth, have ", err)
+	}
+	proofs, err := auth.GenerateProofs(0)
+	if err != nil {
+		t.Fatal("Expected no error while generating proofs, have ", err)
+	}
+	if response := post(t, handler.Auth(), proofs.AuthRequest(infos[1].SRPSession)); response.Code != http.StatusUnauthorized {
+		t.Fatal("Expected the auth request of an unknown user to be rejected but have ", response.Code)
+	}
+}
+
+type countingObserver struct {
+	srp.NopObserver
+	issued, verified int
+	rejected         []srp.RejectReason
+}
+
+func (o *countingObserver) ChallengeIssued(srp.ServerEvent) { o.issued++ }
+func (o *countingObserver) ProofVerified(srp.ServerEvent)   { o.verified++ }
+func (o *countingObserver) ProofRejected(event srp.ServerEvent) {
+	o.rejected = append(o.rejected, event.Reason)
+}
+
+func TestHandlerObserver(t *testing.T) {
+	observer := &countingObserver{}
+	handler := newTestHandler(t, WithObserver(observer))
+
+	response := post(t, handler.Info(), &InfoRequest{Username: "jakubqa"})
+	var info srp.AuthInfo
+	if err := json.Unmarshal(response.Body.Bytes(), &info); err != nil {
+		t.Fatal("Expected no error while decoding info, have ", err)
+	}
+	auth, err := srp.NewAuthFromInfo(&info, "jakubqa", []byte("abc123"))
+	if err != nil {
+		t.Fatal("Expected no error while creating auth, have ", err)
+	}
+	proofs, err := auth.GenerateProofs(0)
+	if err != nil {
+		t.Fatal("Expected no error while generating proofs, have ", err)
+	}
+	for i := 0; i < 2; i++ {
+		post(t, handler.Auth(), proofs.AuthRequest(info.SRPSession))
+	}
+
+	if observer.issued != 1 || observer.verified != 1 {
+		t.Fatal("Expected one challenge and one verification but have ", observer.issued, observer.verified)
+	}
+	if len(observer.rejected) != 1 || observer.rejected[0] != srp.RejectSessionNotFound {
+		t.Fatal("Expected the replay to be rejected as RejectSessionNotFound but have ", observer.rejected)
+	}
+}
+
+func TestHandlerLimiter(t *testing.T) {
+	handler := newTestHandler(t, WithLimiter(srp.NewMemoryLimiter(10, time.Hour, time.Minute, time.Hour)))
+
+	response := post(t, handler.Info(), &InfoRequest{Username: "jakubqa"})
+	var info srp.AuthInfo
+	if err := json.Unmarshal(response.Body.Bytes(), &info); err != nil {
+		t.Fatal("Expected no error while decoding info, have ", err)
+	}
+	auth, err := srp.NewAuthFromInfo(&info, "jakubqa", []byte("wrong"))
+	if err != nil {
+		t.Fatal("Expected no error while creating auth, have ", err)
+	}
+	proofs, err := auth.GenerateProofs(0)
+	if err != nil {
+		t.Fatal("Expected no error while generating proofs, have ", err)
+	}
+	if response := post(t, handler.Auth(), proofs.AuthRequest(info.SRPSession)); response.Code != http.StatusUnauthorized {
+		t.Fatal("Expected a wrong password to be rejected but have ", response.Code)
+	}
+
+	response = post(t, handler.Info(), &InfoRequest{Username: "jakubqa"})
+	if response.Code != http.StatusTooManyRequests || response.Header().Get("Retry-After") != "60" {
+		t.Fatal("Expected the info request to be rate limited for a minute but have ", response.Code, response.Header().Get("Retry-After"))
+	}
+}
+
+func TestHandlerLegacyUpgrade(t *testing.T) {
+	bundle, err := srp.NewVerifierBundle([]byte("abc123"), testModulusClearSign, srp.WithVersion(2), srp.WithUsername("jakubqa"))
+	if err != nil {
+		t.Fatal("Expected no error while generating verifier bundle, have ", err)
+	}
+	accounts := AccountLookupFunc(func(ctx context.Context, username string) (*Account, error) {
+		return &Account{
+			Version:       bundle.Version,
+			SignedModulus: testModulusClearSign,
+			Verifier:      bundle.Verifier,
+		}, nil
+	})
+	handler := NewHandler(accounts, srp.NewMemorySessionStore(time.Minute, 0))
+
+	response := post(t, handler.Info(), &InfoRequest{Username: "jakubqa"})
+	var info srp.AuthInfo
+	if err := json.Unmarshal(response.Body.Bytes(), &info); err != nil {
+		t.Fatal("Expected no error while decoding info, have ", err)
+	}
+	auth, err := srp.NewAuthFromInfo(&info, "jakubqa", []byte("abc123"))
+	if err != nil {
+		t.Fatal("Expected no error while creating auth, have ", err)
+	}
+	proofs, err := auth.GenerateProofs(0)
+	if err != nil {
+		t.Fatal("Expected no error while generating proofs, have ", err)
+	}
+
+	response = post(t, handler.Auth(), proofs.AuthRequest(info.SRPSession))
+	var authResponse AuthResponse
+	if err := json.Unmarshal(response.Body.Bytes(), &authResponse); err != nil {
+		t.Fatal("Expected no error while decoding auth response, have ", err)
+	}
+	if response.Code != http.StatusOK || !authResponse.UpgradeRequired {
+		t.Fatal("Expected the legacy login to succeed and require an upgrade but have ", response.Code, authResponse.UpgradeRequired)
+	}
+}
+
+func TestHandlerLimiterKey(t *testing.T) {
+	handler := newTestHandler(t,
+		WithLimiter(srp.NewMemoryLimiter(1, time.Hour, time.Minute, time.Hour)),
+		WithLimiterKey(func(r *http.Request, username string) string {
+			return username + " " + r.Header.Get("X-Client")
+		}),
+	)
+
+	for _, tt := range []struct {
+		client string
+		code   int
+	}{
+		{client: "a", code: http.StatusOK},
+		{client: "a", code: http.StatusTooManyRequests},
+		{client: "b", code: http.StatusOK},
+	} {
+		data, err := json.Marshal(&InfoRequest{Username: "jakubqa"})
+		if err != nil {
+			t.Fatal("Expected no error while encoding request, have ", err)
+		}
+		request := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data))
+		request.Header.Set("X-Client", tt.client)
+		recorder := httptest.NewRecorder()
+		handler.Info().ServeHTTP(recorder, request)
+		if recorder.Code != tt.code {
+			t.Fatalf("Expected the status %d for client %s but have %d", tt.code, tt.client, recorder.Code)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+// ServerStateVersion is the format version written by Server.MarshalBinary.
+const ServerStateVersion = 2
+
+// serverStateAD is the additional data authenticated by Server.Seal.
+var serverStateAD = []byte("go-srp server state")
+
+// MarshalBinary serializes the server state, so that VerifyProofs can be called
+// on another instance restored with UnmarshalServer. The state holds the server
+// secret: it must be kept confidential, e.g. with Seal.
+//
+// The format is a version byte, the bit length as a big-endian uint32, the
+// challenge lifetime in nanoseconds and its expiry in Unix nanoseconds, or 0,
+// as big-endian uint64, then the suite name, modulus, verifier, secret,
+// ephemeral, shared session, username and salt, each prefixed by its length as
+// a big-endian uint32.
+func (s *Server) MarshalBinary() ([]byte, error) {
+	if s.used {
+		return nil, ErrChallengeAlreadyUsed
+	}
+
+	var expires int64
+	if !s.expires.IsZero() {
+		expires = s.expires.UnixNano()
+	}
+	var ephemeral []byte
+	if s.serverEphemeral != nil {
+		ephemeral = s.group.fromNat(s.serverEphemeral)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(ServerStateVersion)
+	writeUint32(&buf, uint32(s.group.bitLength))
+	writeUint64(&buf, uint64(s.lifetime))
+	writeUint64(&buf, uint64(expires))
+	for _, field := range [][]byte{
+		[]byte(s.group.suite.name),
+		s.group.modulusBytes,
+		s.group.fromNat(s.verifier),
+		s.group.fromNat(s.serverSecret),
+		ephemeral,
+		s.sharedSession,
+		[]byte(s.username),
+		s.salt,
+	} {
+		writeUint32(&buf, uint32(len(field)))
+		buf.Write(field)
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalServer restores a server serialized with MarshalBinary. The modulus
+// is validated again unless it matches a group set with WithGroup, which is also
+// required for suites created with NewSuite. The challenge lifetime is checked
+// with the clock set with WithClock. The observer, limiter, random reader and
+// auth version are not serialized, and are set again with their options.
+// Each restored server is unused, so a state must be restored only once, see
+// Server.Seal.
+func UnmarshalServer(data []byte, opts ...Option) (*Server, error) {
+	if len(data) < 1 {
+		return nil, ErrInvalidServerState
+	}
+	if data[0] != ServerStateVersion {
+		return nil, ErrUnsupportedServerState
+	}
+	if len(data) < 21 {
+		return nil, ErrInvalidServerState
+	}
+	bitLength := int(binary.BigEndian.Uint32(data[1:5]))
+	lifetime := time.Duration(binary.BigEndian.Uint64(data[5:13]))
+	var expires time.Time
+	if unixNano := int64(binary.BigEndian.Uint64(data[13:21])); unixNano != 0 {
+		expires = time.Unix(0, unixNano)
+	}
+
+	r := &stateReader{data: data[21:]}
+	suiteName := string(r.next())
+	modulusBytes := r.next()
+	verifier := r.next()
+	secret := r.next()
+	ephemeral := r.next()
+	sharedSession := r.next()
+	username := string(r.next())
+	salt := r.next()
+	if r.err != nil || len(r.data) != 0 {
+		return nil, ErrInvalidServerState
+	}
+
+	options := newOptions(opts)
+	group, err := stateGroup(options.group, suiteName, modulusBytes, bitLength)
+	if err != nil {
+		return nil, err
+	}
+
+	size := group.bitLength / 8
+	if len(secret) != size ||
+		len(ephemeral) != 0 && len(ephemeral) != size ||
+		len(sharedSession) != 0 && len(sharedSession) != size {
+		return nil, ErrInvalidServerState
+	}
+	if err := group.checkVerifier(verifier); err != nil {
+		return nil, err
+	}
+	if err := group.checkSecret(secret); err != nil {
+		return nil, err
+	}
+	serverSecret := group.toNat(secret)
+
+	server := &Server{
+		group:        group,
+		verifier:     group.toNat(verifier),
+		serverSecret: serverSecret,
+		username:     username,
+		lifetime:     lifetime,
+		clock:        clockOrDefault(options.clock),
+		randReader:   options.randReader,
+		observer:     options.observer,
+		limiter:      options.limiter,
+		limiterKey:   limiterKeyOrDefault(options.limiterKey, username),
+		legacy:       options.version != nil && IsLegacyVersion(*options.version),
+		expires:      expires,
+	}
+	if len(ephemeral) != 0 {
+		server.serverEphemeral = group.toNat(ephemeral)
+	}
+	if len(sharedSession) != 0 {
+		server.sharedSession = append([]byte{}, sharedSession...)
+	}
+	if len(salt) != 0 {
+		server.salt = append([]byte{}, salt...)
+	}
+	return server, nil
+}
+
+// Seal serializes the server state and encrypts it with aead, so that it can be
+// stored in a shared cache. The random nonce, read from the WithRandReader
+// reader of the server, is prepended to the ciphertext.
+//
+// A sealed state can be opened any number of times, and each OpenServer returns
+// a fresh server with the same secret. The single use of the challenge must
+// come from the store, which must take the state out atomically as
+// SessionStore.Take does. Do not hand the sealed state to the client.
+func (s *Server) Seal(aead cipher.AEAD) ([]byte, error) {
+	state, err := s.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	defer clear(state)
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(state)+aead.Overhead())
+	if _, err := io.ReadFull(randReaderOrDefault(s.randReader), nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, state, serverStateAD), nil
+}
+
+// OpenServer decrypts a state sealed with Server.Seal and restores the server,
+// see UnmarshalServer.
+func OpenServer(aead cipher.AEAD, sealed []byte, opts ...Option) (*Server, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrInvalidServerState
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	state, err := aead.Open(nil, nonce, ciphertext, serverStateAD)
+	if err != nil {
+		return nil, ErrInvalidServerState
+	}
+	defer clear(state)
+	return UnmarshalServer(state, opts...)
+}
+
+// stateGroup returns the group of a serialized server state.
+func stateGroup(group *Group, suiteName string, modulusBytes []byte, bitLength int) (*Group, error) {
+	if group != nil && group.suite.name == suiteName && group.matches(modulusBytes, bitLength) {
+		return group, nil
+	}
+
+	var suite *Suite
+	switch suiteName {
+	case SuiteProton.name:
+		return NewGroup(modulusBytes, bitLength)
+	case SuiteRFC5054SHA1.name:
+		suite = SuiteRFC5054SHA1
+	case SuiteRFC5054SHA256.name:
+		suite = SuiteRFC5054SHA256
+	default:
+		return nil, ErrUnsupportedSuite
+	}
+	rfcGroup, err := RFC5054Group(bitLength, suite)
+	if err != nil {
+		return nil, err
+	}
+	if !rfcGroup.matches(modulusBytes, bitLength) {
+		return nil, ErrInvalidServerState
+	}
+	return rfcGroup, nil
+}
+
+func writeUint32(buf *bytes.Buffer, value uint32) {
+	var encoded [4]byte
+	binary.BigEndian.PutUint32(encoded[:], value)
+	buf.Write(encoded[:])
+}
+
+func writeUint64(buf *bytes.Buffer, value uint64) {
+	var encoded [8]byte
+	binary.BigEndian.PutUint64(encoded[:], value)
+	buf.Write(encoded[:])
+}
+
+// stateReader reads the length prefixed fields of a serialized server state.
+type stateReader struct {
+	data []byte
+	err  error
+}
+
+func (r *stateReader) next() []byte {
+	if r.err != nil {
+		return nil
+	}
+	if len(r.data) < 4 {
+		r.err = ErrInvalidServerState
+		return nil
+	}
+	size := binary.BigEndian.Uint32(r.data)
+	r.data = r.data[4:]
+	if uint64(size) > uint64(len(r.data)) {
+		r.err = ErrInvalidServerState
+		return nil
+	}
+	field := r.data[:size]
+	r.data = r.data[size:]
+	return field
+}
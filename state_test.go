@@ -0,0 +1,205 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"testing"
+)
+
+func newTestChallenge(t *testing.T, opts ...Option) (*PasswordKey, *Server, []byte) {
+	t.Helper()
+	key, err := NewPasswordKey(4, "jakubqa", []byte("abc123"), "yKlc5/CvObfoiw==", testModulusClearSign)
+	if err != nil {
+		t.Fatal("Expected no error while hashing password, have ", err)
+	}
+	verifier, err := key.GenerateVerifier(0)
+	if err != nil {
+		t.Fatal("Expected no error while generating verifier, have ", err)
+	}
+	server, err := NewServerWithOptions(key.Modulus, verifier, 0, opts...)
+	if err != nil {
+		t.Fatal("Expected no error while creating server, have ", err)
+	}
+	serverEphemeral, err := server.GenerateChallenge()
+	if err != nil {
+		t.Fatal("Expected no error while generating challenge, have ", err)
+	}
+	return key, server, serverEphemeral
+}
+
+func TestServerMarshalBinary(t *testing.T) {
+	key, server, serverEphemeral := newTestChallenge(t)
+
+	state, err := server.MarshalBinary()
+	if err != nil {
+		t.Fatal("Expected no error while serializing server, have ", err)
+	}
+	restored, err := UnmarshalServer(state)
+	if err != nil {
+		t.Fatal("Expected no error while restoring server, have ", err)
+	}
+
+	proofs, err := key.GenerateProofs(serverEphemeral, 0)
+	if err != nil {
+		t.Fatal("Expected no error while generating proofs, have ", err)
+	}
+	serverProof, err := restored.VerifyProofs(proofs.ClientEphemeral, proofs.ClientProof)
+	if err != nil {
+		t.Fatal("Expected no error while verifying client proof on restored server, have ", err)
+	}
+	if err := proofs.VerifyServerProof(serverProof); err != nil {
+		t.Fatal("Expected no error while verifying server proof, have ", err)
+	}
+
+	for _, version := range []byte{1, ServerStateVersion + 1} {
+		unsupported := append([]byte{}, state...)
+		unsupported[0] = version
+		if _, err := UnmarshalServer(unsupported); !errors.Is(err, ErrUnsupportedServerState) {
+			t.Fatal("Expected the ErrUnsupportedServerState but have ", err)
+		}
+	}
+	for _, malformed := range [][]byte{nil, state[:5], state[:len(state)-1], append(append([]byte{}, state...), 0)} {
+		if _, err := UnmarshalServer(malformed); !errors.Is(err, ErrInvalidServerState) {
+			t.Fatal("Expected the ErrInvalidServerState but have ", err)
+		}
+	}
+}
+
+func TestServerMarshalBinaryGroups(t *testing.T) {
+	modulus, err := base64.StdEncoding.DecodeString(testModulus)
+	if err != nil {
+		t.Fatal("Expected no error but have ", err)
+	}
+	customGroup, err := NewGroupForSuite(NewSuite("proton-shake256", SHAKE256Expander), modulus, 0)
+	if err != nil {
+		t.Fatal("Expected no error while creating group, have ", err)
+	}
+	rfcGroup, err := RFC5054Group(2048, SuiteRFC5054SHA256)
+	if err != nil {
+		t.Fatal("Expected no error while creating group, have ", err)
+	}
+
+	salt := []byte("0123456789")
+	for _, group := range []*Group{customGroup, rfcGroup} {
+		key, err := NewPasswordKeyForGroup(group, 4, "jakubqa", []byte("abc123"), salt)
+		if err != nil {
+			t.Fatal("Expected no error while hashing password, have ", err)
+		}
+		verifier, err := key.GenerateVerifier(0)
+		if err != nil {
+			t.Fatal("Expected no error while generating verifier, have ", err)
+		}
+		server, err := NewServerWithOptions(group.Modulus(), verifier, 0, WithGroup(group), WithUsername("jakubqa"), WithSalt(salt))
+		if err != nil {
+			t.Fatal("Expected no error while creating server, have ", err)
+		}
+		serverEphemeral, err := server.GenerateChallenge()
+		if err != nil {
+			t.Fatal("Expected no error while generating challenge, have ", err)
+		}
+		state, err := server.MarshalBinary()
+		if err != nil {
+			t.Fatal("Expected no error while serializing server, have ", err)
+		}
+
+		if group == customGroup {
+			if _, err := UnmarshalServer(state); !errors.Is(err, ErrUnsupportedSuite) {
+				t.Fatal("Expected the ErrUnsupportedSuite without the custom group but have ", err)
+			}
+		}
+		restored, err := UnmarshalServer(state, WithGroup(group))
+		if err != nil {
+			t.Fatal("Expected no error while restoring server, have ", err)
+		}
+		if restored.group != group {
+			t.Fatal("Expected the restored server to share the group")
+		}
+
+		proofs, err := key.GenerateProofs(serverEphemeral, 0)
+		if err != nil {
+			t.Fatal("Expected no error while generating proofs, have ", err)
+		}
+		if _, err := restored.VerifyProofs(proofs.ClientEphemeral, proofs.ClientProof); err != nil {
+			t.Fatal("Expected no error while verifying client proof on restored server, have ", err)
+		}
+	}
+}
+
+type countingReader struct {
+	reader io.Reader
+	count  int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count += n
+	return n, err
+}
+
+func TestServerSeal(t *testing.T) {
+	block, err := aes.NewCipher(make([]byte, 32))
+	if err != nil {
+		t.Fatal("Expected no error while creating cipher, have ", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal("Expected no error while creating AEAD, have ", err)
+	}
+
+	reader := &countingReader{reader: rand.Reader}
+	key, server, serverEphemeral := newTestChallenge(t, WithRandReader(reader))
+	read := reader.count
+	sealed, err := server.Seal(aead)
+	if err != nil {
+		t.Fatal("Expected no error while sealing server, have ", err)
+	}
+	if reader.count-read != aead.NonceSize() {
+		t.Fatal("Expected the nonce to be read from the reader of the server")
+	}
+	restored, err := OpenServer(aead, sealed)
+	if err != nil {
+		t.Fatal("Expected no error while opening server, have ", err)
+	}
+
+	proofs, err := key.GenerateProofs(serverEphemeral, 0)
+	if err != nil {
+		t.Fatal("Expected no error while generating proofs, have ", err)
+	}
+	if _, err := restored.VerifyProofs(proofs.ClientEphemeral, proofs.ClientProof); err != nil {
+		t.Fatal("Expected no error while verifying client proof on opened server, have ", err)
+	}
+
+	sealed[len(sealed)-1] ^= 1
+	if _, err := OpenServer(aead, sealed); !errors.Is(err, ErrInvalidServerState) {
+		t.Fatal("Expected the ErrInvalidServerState for a tampered state but have ", err)
+	}
+	if _, err := OpenServer(aead, sealed[:4]); !errors.Is(err, ErrInvalidServerState) {
+		t.Fatal("Expected the ErrInvalidServerState for a truncated state but have ", err)
+	}
+}
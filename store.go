@@ -0,0 +1,209 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+import (
+	"container/list"
+	"encoding/base64"
+	"io"
+	"sync"
+	"time"
+)
+
+// SessionIDLength is the number of random bytes of a session ID.
+const SessionIDLength = 16
+
+// SessionStore keeps the servers waiting for the client proofs, by SRP session
+// ID. Implementations must be safe for concurrent use. Stores shared by several
+// nodes can persist the servers with Server.MarshalBinary or Server.Seal.
+type SessionStore interface {
+	// Put stores the server under the session ID.
+	Put(sessionID string, server *Server) error
+	// Get returns the server stored under the session ID, or ErrSessionNotFound.
+	Get(sessionID string) (*Server, error)
+	// Take removes the session ID and returns its server, or ErrSessionNotFound.
+	// It must be atomic: of concurrent calls for the same session ID, at most
+	// one gets the server, e.g. with GETDEL in a shared cache.
+	Take(sessionID string) (*Server, error)
+	// Delete removes the session ID. Deleting a missing session is not an error.
+	Delete(sessionID string) error
+}
+
+// NewSession creates a server for the verifier, generates its challenge and
+// stores it under a new random session ID.
+func NewSession(store SessionStore, modulusBytes, verifier []byte, opts ...Option) (sessionID string, serverEphemeral []byte, err error) {
+	server, err := NewServerWithOptions(modulusBytes, verifier, 0, opts...)
+	if err != nil {
+		return "", nil, err
+	}
+	serverEphemeral, err = server.GenerateChallenge()
+	if err != nil {
+		return "", nil, err
+	}
+
+	id := make([]byte, SessionIDLength)
+	if _, err := io.ReadFull(randReaderOrDefault(newOptions(opts).randReader), id); err != nil {
+		return "", nil, err
+	}
+	sessionID = base64.RawURLEncoding.EncodeToString(id)
+	if err := store.Put(sessionID, server); err != nil {
+		return "", nil, err
+	}
+	return sessionID, serverEphemeral, nil
+}
+
+// VerifySession takes the server of the session out of the store and verifies
+// the client proofs. The session is removed whatever the result, so each
+// challenge can be answered only once, even by concurrent requests. The server
+// is returned on success to access the shared session.
+func VerifySession(store SessionStore, sessionID string, clientEphemeral, clientProof []byte) (server *Server, serverProof []byte, err error) {
+	server, err = store.Take(sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serverProof, err = server.VerifyProofs(clientEphemeral, clientProof)
+	if err != nil {
+		return nil, nil, err
+	}
+	return server, serverProof, nil
+}
+
+// MemorySessionStore is a SessionStore keeping the servers in memory. Sessions
+// expire after a fixed time to live, and the oldest sessions are evicted once
+// the capacity is reached.
+type MemorySessionStore struct {
+	ttl      time.Duration
+	capacity int
+	now      func() time.Time
+
+	mu       sync.Mutex
+	sessions map[string]*list.Element
+	order    *list.List // of *memorySession, oldest first
+}
+
+type memorySession struct {
+	id      string
+	server  *Server
+	expires time.Time
+}
+
+// NewMemorySessionStore creates an empty store whose sessions expire after ttl,
+// holding at most capacity sessions. A ttl of 0 means the sessions never expire
+// and are only evicted for capacity, a capacity of 0 means no limit.
+func NewMemorySessionStore(ttl time.Duration, capacity int) *MemorySessionStore {
+	return &MemorySessionStore{
+		ttl:      ttl,
+		capacity: capacity,
+		now:      time.Now,
+		sessions: make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Put stores the server under the session ID, replacing any previous session.
+func (m *MemorySessionStore) Put(sessionID string, server *Server) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.evictExpired(now)
+	m.remove(sessionID)
+	for m.capacity > 0 && m.order.Len() >= m.capacity {
+		m.remove(m.order.Front().Value.(*memorySession).id)
+	}
+	m.sessions[sessionID] = m.order.PushBack(&memorySession{
+		id:      sessionID,
+		server:  server,
+		expires: now.Add(m.ttl),
+	})
+	return nil
+}
+
+// Get returns the server stored under the session ID, or ErrSessionNotFound if
+// it is missing or expired.
+func (m *MemorySessionStore) Get(sessionID string) (*Server, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.evictExpired(m.now())
+	element, ok := m.sessions[sessionID]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	return element.Value.(*memorySession).server, nil
+}
+
+// Take removes the session ID and returns its server, or ErrSessionNotFound if
+// it is missing or expired.
+func (m *MemorySessionStore) Take(sessionID string) (*Server, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.evictExpired(m.now())
+	element, ok := m.sessions[sessionID]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	m.remove(sessionID)
+	return element.Value.(*memorySession).server, nil
+}
+
+// Delete removes the session ID.
+func (m *MemorySessionStore) Delete(sessionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remove(sessionID)
+	return nil
+}
+
+// Len returns the number of sessions stored, including the expired sessions not
+// evicted yet.
+func (m *MemorySessionStore) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.order.Len()
+}
+
+// evictExpired removes the expired sessions. The time to live is fixed, so the
+// sessions expire in insertion order.
+func (m *MemorySessionStore) evictExpired(now time.Time) {
+	if m.ttl <= 0 {
+		return
+	}
+	for front := m.order.Front(); front != nil; front = m.order.Front() {
+		if now.Before(front.Value.(*memorySession).expires) {
+			return
+		}
+		m.remove(front.Value.(*memorySession).id)
+	}
+}
+
+func (m *MemorySessionStore) remove(sessionID string) {
+	if element, ok := m.sessions[sessionID]; ok {
+		m.order.Remove(element)
+		delete(m.sessions, sessionID)
+	}
+}
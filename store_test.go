@@ -0,0 +1,221 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemorySessionStore(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	store := NewMemorySessionStore(time.Minute, 2)
+	store.now = func() time.Time { return now }
+
+	servers := []*Server{{}, {}, {}}
+	for i, id := range []string{"a", "b"} {
+		if err := store.Put(id, servers[i]); err != nil {
+			t.Fatal("Expected no error while storing session, have ", err)
+		}
+	}
+	if server, err := store.Get("a"); err != nil || server != servers[0] {
+		t.Fatal("Expected the stored server but have ", err)
+	}
+
+	if err := store.Put("c", servers[2]); err != nil {
+		t.Fatal("Expected no error while storing session, have ", err)
+	}
+	if store.Len() != 2 {
+		t.Fatal("Expected the store to be capped to 2 sessions but have ", store.Len())
+	}
+	if _, err := store.Get("a"); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatal("Expected the oldest session to be evicted but have ", err)
+	}
+
+	now = now.Add(30 * time.Second)
+	if err := store.Put("a", servers[0]); err != nil {
+		t.Fatal("Expected no error while storing session, have ", err)
+	}
+	now = now.Add(30 * time.Second)
+	if _, err := store.Get("b"); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatal("Expected the session to expire but have ", err)
+	}
+	if store.Len() != 1 {
+		t.Fatal("Expected the expired sessions to be evicted but have ", store.Len())
+	}
+	if server, err := store.Get("a"); err != nil || server != servers[0] {
+		t.Fatal("Expected the recent session to be kept but have ", err)
+	}
+
+	if server, err := store.Take("a"); err != nil || server != servers[0] {
+		t.Fatal("Expected to take the stored server but have ", err)
+	}
+	if _, err := store.Take("a"); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatal("Expected a taken session to be removed but have ", err)
+	}
+	if err := store.Put("a", servers[0]); err != nil {
+		t.Fatal("Expected no error while storing session, have ", err)
+	}
+	if err := store.Delete("a"); err != nil {
+		t.Fatal("Expected no error while deleting session, have ", err)
+	}
+	if err := store.Delete("a"); err != nil {
+		t.Fatal("Expected no error while deleting a missing session, have ", err)
+	}
+	if store.Len() != 0 {
+		t.Fatal("Expected an empty store but have ", store.Len())
+	}
+}
+
+func TestMemorySessionStoreNoExpiry(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	store := NewMemorySessionStore(0, 1)
+	store.now = func() time.Time { return now }
+
+	server := &Server{}
+	if err := store.Put("a", server); err != nil {
+		t.Fatal("Expected no error while storing session, have ", err)
+	}
+	now = now.Add(24 * time.Hour)
+	if stored, err := store.Get("a"); err != nil || stored != server {
+		t.Fatal("Expected a ttl of 0 to keep the session but have ", err)
+	}
+	if err := store.Put("b", &Server{}); err != nil {
+		t.Fatal("Expected no error while storing session, have ", err)
+	}
+	if _, err := store.Get("a"); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatal("Expected the oldest session to be evicted for capacity but have ", err)
+	}
+}
+
+func TestSession(t *testing.T) {
+	key, err := NewPasswordKey(4, "jakubqa", []byte("abc123"), "yKlc5/CvObfoiw==", testModulusClearSign)
+	if err != nil {
+		t.Fatal("Expected no error while hashing password, have ", err)
+	}
+	verifier, err := key.GenerateVerifier(0)
+	if err != nil {
+		t.Fatal("Expected no error while generating verifier, have ", err)
+	}
+	store := NewMemorySessionStore(time.Minute, 0)
+
+	sessionID, serverEphemeral, err := NewSession(store, key.Modulus, verifier)
+	if err != nil {
+		t.Fatal("Expected no error while creating session, have ", err)
+	}
+	proofs, err := key.GenerateProofs(serverEphemeral, 0)
+	if err != nil {
+		t.Fatal("Expected no error while generating proofs, have ", err)
+	}
+	server, serverProof, err := VerifySession(store, sessionID, proofs.ClientEphemeral, proofs.ClientProof)
+	if err != nil {
+		t.Fatal("Expected no error while verifying session, have ", err)
+	}
+	if err := proofs.VerifyServerProof(serverProof); err != nil {
+		t.Fatal("Expected no error while verifying server proof, have ", err)
+	}
+	if !server.IsCompleted() {
+		t.Fatal("Expected the server to be completed")
+	}
+	if _, _, err := VerifySession(store, sessionID, proofs.ClientEphemeral, proofs.ClientProof); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatal("Expected the ErrSessionNotFound when replaying the proofs but have ", err)
+	}
+
+	sessionID, serverEphemeral, err = NewSession(store, key.Modulus, verifier)
+	if err != nil {
+		t.Fatal("Expected no error while creating session, have ", err)
+	}
+	proofs, err = key.GenerateProofs(serverEphemeral, 0)
+	if err != nil {
+		t.Fatal("Expected no error while generating proofs, have ", err)
+	}
+	proofs.ClientProof[0] ^= 1
+	if _, _, err := VerifySession(store, sessionID, proofs.ClientEphemeral, proofs.ClientProof); !errors.Is(err, ErrInvalidClientProof) {
+		t.Fatal("Expected the ErrInvalidClientProof but have ", err)
+	}
+	if store.Len() != 0 {
+		t.Fatal("Expected the session to be deleted after a failed verification")
+	}
+}
+
+// slowSessionStore delays every call, like a networked store.
+type slowSessionStore struct {
+	*MemorySessionStore
+}
+
+func (s slowSessionStore) Get(sessionID string) (*Server, error) {
+	time.Sleep(10 * time.Millisecond)
+	return s.MemorySessionStore.Get(sessionID)
+}
+
+func (s slowSessionStore) Take(sessionID string) (*Server, error) {
+	time.Sleep(10 * time.Millisecond)
+	return s.MemorySessionStore.Take(sessionID)
+}
+
+func TestSessionConcurrent(t *testing.T) {
+	key, err := NewPasswordKey(4, "jakubqa", []byte("abc123"), "yKlc5/CvObfoiw==", testModulusClearSign)
+	if err != nil {
+		t.Fatal("Expected no error while hashing password, have ", err)
+	}
+	verifier, err := key.GenerateVerifier(0)
+	if err != nil {
+		t.Fatal("Expected no error while generating verifier, have ", err)
+	}
+	store := slowSessionStore{NewMemorySessionStore(time.Minute, 0)}
+
+	sessionID, serverEphemeral, err := NewSession(store, key.Modulus, verifier)
+	if err != nil {
+		t.Fatal("Expected no error while creating session, have ", err)
+	}
+	proofs, err := key.GenerateProofs(serverEphemeral, 0)
+	if err != nil {
+		t.Fatal("Expected no error while generating proofs, have ", err)
+	}
+
+	errs := make([]error, 4)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, _, errs[i] = VerifySession(store, sessionID, proofs.ClientEphemeral, proofs.ClientProof)
+		}(i)
+	}
+	wg.Wait()
+
+	var verified int
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			verified++
+		case !errors.Is(err, ErrSessionNotFound):
+			t.Fatal("Expected the concurrent replays to get ErrSessionNotFound but have ", err)
+		}
+	}
+	if verified != 1 {
+		t.Fatal("Expected exactly one concurrent verification to succeed but have ", verified)
+	}
+}
@@ -0,0 +1,203 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"hash"
+	"math/big"
+
+	"github.com/cronokirby/saferith"
+)
+
+// Suite is a variant of the SRP-6a protocol. It defines how numbers are encoded
+// and how the multiplier, the scrambling parameter, the password hash and the
+// proofs are computed. The suite is carried by the Group, so client and server
+// sharing a group always agree on it.
+type Suite struct {
+	name     string
+	hash     func() hash.Hash
+	expander Expander
+}
+
+var (
+	// SuiteProton is the variant used by Proton: little-endian numbers, hashes
+	// expanded from SHA-512 to the modulus size, M1 = H(A | B | S) and
+	// M2 = H(A | M1 | S).
+	SuiteProton = &Suite{name: "proton", expander: SHA512Expander}
+
+	// SuiteRFC5054SHA1 follows RFC 5054 and RFC 2945 with SHA-1: big-endian
+	// numbers, k = H(N | PAD(g)), u = H(PAD(A) | PAD(B)),
+	// x = H(s | H(I | ":" | P)), K = H(S),
+	// M1 = H(H(N) xor H(g) | H(I) | s | A | B | K) and M2 = H(A | M1 | K).
+	SuiteRFC5054SHA1 = &Suite{name: "rfc5054-sha1", hash: sha1.New}
+
+	// SuiteRFC5054SHA256 is SuiteRFC5054SHA1 with SHA-256.
+	SuiteRFC5054SHA256 = &Suite{name: "rfc5054-sha256", hash: sha256.New}
+)
+
+// NewSuite returns a variant of SuiteProton expanding every hash with the given
+// expander, such as SHAKE256Expander or BLAKE2bExpander. Groups of the suite are
+// created with NewGroupForSuite.
+func NewSuite(name string, expander Expander) *Suite {
+	return &Suite{name: name, expander: expander}
+}
+
+// Name returns the name of the suite.
+func (s *Suite) Name() string {
+	return s.name
+}
+
+func (s *Suite) isRFC5054() bool {
+	return s.hash != nil
+}
+
+// digest hashes the concatenation of data with the hash of an RFC 5054 suite.
+func (s *Suite) digest(data ...[]byte) []byte {
+	h := s.hash()
+	for _, d := range data {
+		_, _ = h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+func (s *Suite) toInt(arr []byte) *big.Int {
+	if s.isRFC5054() {
+		return new(big.Int).SetBytes(arr)
+	}
+	return toInt(arr)
+}
+
+func (s *Suite) fromInt(bitLength int, num *big.Int) []byte {
+	if s.isRFC5054() {
+		arr := num.Bytes()
+		padded := make([]byte, bitLength/8)
+		copy(padded[len(padded)-len(arr):], arr)
+		return padded
+	}
+	return fromInt(bitLength, num)
+}
+
+func (s *Suite) toNat(arr []byte) *saferith.Nat {
+	if s.isRFC5054() {
+		return new(saferith.Nat).SetBytes(arr)
+	}
+	return toNat(arr)
+}
+
+func (s *Suite) fromNat(bitLength int, nat *saferith.Nat) []byte {
+	if s.isRFC5054() {
+		return nat.FillBytes(make([]byte, bitLength/8))
+	}
+	return fromNat(bitLength, nat)
+}
+
+func (s *Suite) toModulus(arr []byte) *saferith.Modulus {
+	if s.isRFC5054() {
+		return saferith.ModulusFromBytes(arr)
+	}
+	return toModulus(arr)
+}
+
+// hashPassword returns the password hash x. The RFC 5054 suites ignore the
+// version and compute x = H(s | H(I | ":" | P)).
+func (s *Suite) hashPassword(version int, password []byte, username string, salt, modulus []byte) ([]byte, error) {
+	if !s.isRFC5054() {
+		return hashPassword(s.expander, version, password, username, salt, modulus)
+	}
+	identity := append([]byte(username+":"), password...)
+	defer clear(identity)
+	return s.digest(salt, s.digest(identity)), nil
+}
+
+func (s *Suite) computeMultiplier(generator, modulus *big.Int, bitLength int) (*saferith.Nat, error) {
+	if !s.isRFC5054() {
+		return computeMultiplier(s.expander, generator, modulus, bitLength)
+	}
+
+	modulusMinusOne := big.NewInt(0).Sub(modulus, big.NewInt(1))
+	multiplier := s.toInt(s.digest(s.fromInt(bitLength, modulus), s.fromInt(bitLength, generator)))
+	multiplier = multiplier.Mod(multiplier, modulus)
+
+	if multiplier.Cmp(big.NewInt(1)) <= 0 || multiplier.Cmp(modulusMinusOne) >= 0 {
+		return nil, ErrMultiplierOutOfBounds
+	}
+
+	return new(saferith.Nat).SetBig(multiplier, bitLength), nil
+}
+
+// computeScrambleParam computes u from the encoded ephemerals.
+func (g *Group) computeScrambleParam(clientEphemeral, serverEphemeral []byte) *saferith.Nat {
+	if !g.suite.isRFC5054() {
+		return computeScrambleParam(g.suite.expander, g.bitLength, clientEphemeral, serverEphemeral)
+	}
+	return g.suite.toNat(g.suite.digest(g.pad(clientEphemeral), g.pad(serverEphemeral)))
+}
+
+// computeClientProof computes M1 from the encoded ephemerals and shared secret.
+// The username and salt are only bound by the RFC 5054 suites.
+func (g *Group) computeClientProof(username string, salt, clientEphemeral, serverEphemeral, sharedSecret []byte) []byte {
+	if !g.suite.isRFC5054() {
+		return computeClientProof(g.suite.expander, g.bitLength, clientEphemeral, serverEphemeral, sharedSecret)
+	}
+
+	hashModulus := g.suite.digest(g.modulusBytes)
+	hashGenerator := g.suite.digest(g.generator.Bytes())
+	for i := range hashModulus {
+		hashModulus[i] ^= hashGenerator[i]
+	}
+	return g.suite.digest(
+		hashModulus,
+		g.suite.digest([]byte(username)),
+		salt,
+		trimNumber(clientEphemeral),
+		trimNumber(serverEphemeral),
+		g.suite.digest(trimNumber(sharedSecret)),
+	)
+}
+
+// computeServerProof computes M2 from the encoded client ephemeral, client proof
+// and shared secret.
+func (g *Group) computeServerProof(clientEphemeral, clientProof, sharedSecret []byte) []byte {
+	if !g.suite.isRFC5054() {
+		return computeServerProof(g.suite.expander, g.bitLength, clientEphemeral, clientProof, sharedSecret)
+	}
+	return g.suite.digest(trimNumber(clientEphemeral), clientProof, g.suite.digest(trimNumber(sharedSecret)))
+}
+
+// trimNumber strips the leading zeros of a big-endian number, as RFC 2945
+// hashes numbers without padding outside of k and u.
+func trimNumber(arr []byte) []byte {
+	return bytes.TrimLeft(arr, "\x00")
+}
+
+// pad left pads a big-endian number to the size of the modulus.
+func (g *Group) pad(arr []byte) []byte {
+	size := g.bitLength / 8
+	if len(arr) >= size {
+		return arr
+	}
+	return append(bytes.Repeat([]byte{0}, size-len(arr)), arr...)
+}
@@ -0,0 +1,60 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+// IsLegacyVersion returns true for the auth versions 0 to 2, which hash the
+// password with the username instead of a random salt.
+func IsLegacyVersion(version int) bool {
+	return version >= 0 && version < 3
+}
+
+// NeedsUpgrade returns true once the exchange has concluded in valid state for
+// a verifier of a legacy auth version, as set with WithVersion. The client
+// still holds the password at this point, and can send the verifier bundle of
+// UpgradeVerifierBundle to replace the legacy verifier.
+func (s *Server) NeedsUpgrade() bool {
+	return s.legacy && s.IsCompleted()
+}
+
+// VerifyUpgrade checks that the verifier bundle sent by the client after a
+// login with a legacy auth version is well formed for the group, and is not of
+// a legacy version itself. The exchange must have concluded in valid state.
+func (s *Server) VerifyUpgrade(bundle *VerifierBundle, group *Group) error {
+	if !s.IsCompleted() {
+		return ErrNotCompleted
+	}
+	if err := checkVerifierBundle(bundle, group); err != nil {
+		return err
+	}
+	if IsLegacyVersion(bundle.Version) {
+		return ErrInvalidVerifierBundle
+	}
+	return nil
+}
+
+// UpgradeVerifierBundle computes the verifier bundle of the password for the
+// DefaultVersion, to replace a legacy verifier once the server signals it with
+// NeedsUpgrade. A WithVersion option is ignored.
+func UpgradeVerifierBundle(password []byte, signedModulus string, opts ...Option) (*VerifierBundle, error) {
+	return NewVerifierBundle(password, signedModulus, append(append([]Option{}, opts...), WithVersion(DefaultVersion))...)
+}
@@ -0,0 +1,117 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestLegacyUpgrade(t *testing.T) {
+	group, err := NewGroupFromSigned(testModulusClearSign, 0)
+	if err != nil {
+		t.Fatal("Expected no error while creating group, have ", err)
+	}
+	login := func(bundle *VerifierBundle) *Server {
+		t.Helper()
+		server, err := NewServerWithOptions(group.Modulus(), bundle.Verifier, 0, WithGroup(group), WithVersion(bundle.Version))
+		if err != nil {
+			t.Fatal("Expected no error while creating server, have ", err)
+		}
+		serverEphemeral, err := server.GenerateChallenge()
+		if err != nil {
+			t.Fatal("Expected no error while generating challenge, have ", err)
+		}
+		info := &AuthInfo{
+			Version:         bundle.Version,
+			Modulus:         testModulusClearSign,
+			ServerEphemeral: base64.StdEncoding.EncodeToString(serverEphemeral),
+			Salt:            base64.StdEncoding.EncodeToString(bundle.Salt),
+		}
+		auth, err := NewAuthFromInfo(info, "jakubqa", []byte("abc123"), WithGroup(group))
+		if err != nil {
+			t.Fatal("Expected no error while creating auth, have ", err)
+		}
+		proofs, err := auth.GenerateProofs(0)
+		if err != nil {
+			t.Fatal("Expected no error while generating proofs, have ", err)
+		}
+		if server.NeedsUpgrade() {
+			t.Fatal("Expected no upgrade before the proofs are verified")
+		}
+		if _, err := server.VerifyProofs(proofs.ClientEphemeral, proofs.ClientProof); err != nil {
+			t.Fatal("Expected no error while verifying proofs, have ", err)
+		}
+		return server
+	}
+
+	legacy, err := NewVerifierBundle([]byte("abc123"), testModulusClearSign, WithGroup(group), WithVersion(2), WithUsername("jakubqa"))
+	if err != nil {
+		t.Fatal("Expected no error while generating legacy bundle, have ", err)
+	}
+	server := login(legacy)
+	if !server.NeedsUpgrade() {
+		t.Fatal("Expected a login with a legacy version to need an upgrade")
+	}
+
+	opts := make([]Option, 2, 3)
+	opts[0], opts[1] = WithGroup(group), WithVersion(2)
+	spare := opts[:3]
+	spare[2] = WithUsername("jakubqa")
+	bundle, err := UpgradeVerifierBundle([]byte("abc123"), testModulusClearSign, opts...)
+	if err != nil {
+		t.Fatal("Expected no error while generating upgrade bundle, have ", err)
+	}
+	if bundle.Version != DefaultVersion {
+		t.Fatal("Expected the upgrade bundle to use the default version but have ", bundle.Version)
+	}
+	if options := newOptions(spare); options.username != "jakubqa" || *options.version != 2 {
+		t.Fatal("Expected the options of the caller not to be overwritten")
+	}
+	if err := server.VerifyUpgrade(bundle, group); err != nil {
+		t.Fatal("Expected the upgrade bundle to be accepted but have ", err)
+	}
+	if err := server.VerifyUpgrade(legacy, group); !errors.Is(err, ErrInvalidVerifierBundle) {
+		t.Fatal("Expected a legacy bundle to be rejected but have ", err)
+	}
+
+	upgraded := login(bundle)
+	if upgraded.NeedsUpgrade() {
+		t.Fatal("Expected no upgrade for the default version")
+	}
+
+	notCompleted, err := NewServerWithOptions(group.Modulus(), bundle.Verifier, 0, WithGroup(group), WithVersion(0))
+	if err != nil {
+		t.Fatal("Expected no error while creating server, have ", err)
+	}
+	if notCompleted.NeedsUpgrade() {
+		t.Fatal("Expected no upgrade before the exchange concluded")
+	}
+	if err := notCompleted.VerifyUpgrade(bundle, group); !errors.Is(err, ErrNotCompleted) {
+		t.Fatal("Expected the ErrNotCompleted but have ", err)
+	}
+	if IsLegacyVersion(3) || !IsLegacyVersion(0) {
+		t.Fatal("Expected only the versions 0 to 2 to be legacy")
+	}
+}
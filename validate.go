@@ -0,0 +1,112 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+// InputError reports a byte input rejected before any computation. Err is
+// ErrInvalidLength for an input of the wrong size, or the out of bounds error
+// of the input, such as ErrEphemeralOutOfBounds, and can be matched with
+// errors.Is.
+type InputError struct {
+	Input string
+	Err   error
+}
+
+func (e *InputError) Error() string {
+	return e.Err.Error() + " (" + e.Input + ")"
+}
+
+// Unwrap returns Err.
+func (e *InputError) Unwrap() error {
+	return e.Err
+}
+
+// checkEphemeral validates that the ephemeral has the size of the modulus and
+// lies in ]1, N-1[.
+func (g *Group) checkEphemeral(input string, ephemeral []byte) error {
+	if len(ephemeral) != g.bitLength/8 {
+		return &InputError{Input: input, Err: ErrInvalidLength}
+	}
+	ephemeralNat := g.toNat(ephemeral)
+	greaterThanOne, _, _ := ephemeralNat.Cmp(newNat(1))
+	_, _, lessThanModulusMinusOne := ephemeralNat.Cmp(g.modulusMinusOneNat)
+	if greaterThanOne != 1 || lessThanModulusMinusOne != 1 {
+		return &InputError{Input: input, Err: ErrEphemeralOutOfBounds}
+	}
+	return nil
+}
+
+// checkVerifier validates that the verifier has the size of the modulus and
+// lies in [2, N-1].
+func (g *Group) checkVerifier(verifier []byte) error {
+	if len(verifier) != g.bitLength/8 {
+		return &InputError{Input: "verifier", Err: ErrInvalidLength}
+	}
+	verifierNat := g.toNat(verifier)
+	greaterThanOne, _, _ := verifierNat.Cmp(newNat(1))
+	greaterThanModulusMinusOne, _, _ := verifierNat.Cmp(g.modulusMinusOneNat)
+	if greaterThanOne != 1 || greaterThanModulusMinusOne == 1 {
+		return &InputError{Input: "verifier", Err: ErrVerifierOutOfBounds}
+	}
+	return nil
+}
+
+// checkSecret validates that the server secret fits in the modulus and lies in
+// ]2 * bitLength, N-1[, like the generated ones.
+func (g *Group) checkSecret(secret []byte) error {
+	if len(secret) > g.bitLength/8 {
+		return &InputError{Input: "server secret", Err: ErrInvalidLength}
+	}
+	secretNat := g.toNat(secret)
+	notTooSmall, _, _ := secretNat.Cmp(newNat(uint64(g.bitLength * 2)))
+	_, _, notTooLarge := secretNat.Cmp(g.modulusMinusOneNat)
+	if notTooSmall != 1 || notTooLarge != 1 {
+		return &InputError{Input: "server secret", Err: ErrInvalidSecret}
+	}
+	return nil
+}
+
+// checkProof validates that the proof has the size of the proofs of the suite.
+func (g *Group) checkProof(input string, proof []byte) error {
+	if len(proof) != g.proofSize() {
+		return &InputError{Input: input, Err: ErrInvalidLength}
+	}
+	return nil
+}
+
+// proofSize returns the size of the proofs: the size of the modulus for the
+// Proton suites, the hash size for the RFC 5054 suites.
+func (g *Group) proofSize() int {
+	if g.suite.isRFC5054() {
+		return g.suite.hash().Size()
+	}
+	return g.bitLength / 8
+}
+
+// checkSalt validates that the salt of the salted auth versions 3 and 4 has
+// SaltLength bytes.
+func checkSalt(version int, salt []byte) error {
+	if (version == 3 || version == 4) && len(salt) != SaltLength {
+		return &InputError{Input: "salt", Err: ErrInvalidLength}
+	}
+	return nil
+}
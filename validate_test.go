@@ -0,0 +1,141 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+import (
+	"errors"
+	"testing"
+)
+
+func expectInputError(t *testing.T, err error, input string, target error) {
+	t.Helper()
+	var inputErr *InputError
+	if !errors.As(err, &inputErr) || inputErr.Input != input || !errors.Is(err, target) {
+		t.Fatalf("Expected an input error on the %s matching %v but have %v", input, target, err)
+	}
+}
+
+func TestValidateServerInputs(t *testing.T) {
+	key, server, serverEphemeral := newTestChallenge(t)
+	group := server.group
+	size := group.bitLength / 8
+	proofs, err := key.GenerateProofs(serverEphemeral, 0)
+	if err != nil {
+		t.Fatal("Expected no error while generating proofs, have ", err)
+	}
+
+	modulusMinusOne := group.fromInt(group.modulusMinusOneInt)
+	for _, tt := range []struct {
+		ephemeral, proof []byte
+		input            string
+		err              error
+	}{
+		{ephemeral: nil, proof: proofs.ClientProof, input: "client ephemeral", err: ErrInvalidLength},
+		{ephemeral: proofs.ClientEphemeral[1:], proof: proofs.ClientProof, input: "client ephemeral", err: ErrInvalidLength},
+		{ephemeral: append(append([]byte{}, proofs.ClientEphemeral...), 0), proof: proofs.ClientProof, input: "client ephemeral", err: ErrInvalidLength},
+		{ephemeral: make([]byte, size), proof: proofs.ClientProof, input: "client ephemeral", err: ErrEphemeralOutOfBounds},
+		{ephemeral: group.fromInt(toInt([]byte{1})), proof: proofs.ClientProof, input: "client ephemeral", err: ErrEphemeralOutOfBounds},
+		{ephemeral: modulusMinusOne, proof: proofs.ClientProof, input: "client ephemeral", err: ErrEphemeralOutOfBounds},
+		{ephemeral: group.Modulus(), proof: proofs.ClientProof, input: "client ephemeral", err: ErrEphemeralOutOfBounds},
+		{ephemeral: proofs.ClientEphemeral, proof: nil, input: "client proof", err: ErrInvalidLength},
+		{ephemeral: proofs.ClientEphemeral, proof: proofs.ClientProof[:64], input: "client proof", err: ErrInvalidLength},
+	} {
+		_, server, _ := newTestChallenge(t, WithGroup(group))
+		_, err := server.VerifyProofs(tt.ephemeral, tt.proof)
+		expectInputError(t, err, tt.input, tt.err)
+	}
+
+	verifier := group.fromNat(server.verifier)
+	for _, tt := range []struct {
+		verifier []byte
+		err      error
+	}{
+		{verifier: verifier[1:], err: ErrInvalidLength},
+		{verifier: append(append([]byte{}, verifier...), 0), err: ErrInvalidLength},
+		{verifier: make([]byte, size), err: ErrVerifierOutOfBounds},
+		{verifier: group.fromInt(toInt([]byte{1})), err: ErrVerifierOutOfBounds},
+		{verifier: group.Modulus(), err: ErrVerifierOutOfBounds},
+	} {
+		_, err := NewServerWithOptions(group.Modulus(), tt.verifier, 0, WithGroup(group))
+		expectInputError(t, err, "verifier", tt.err)
+	}
+	if _, err := NewServerWithOptions(group.Modulus(), modulusMinusOne, 0, WithGroup(group)); err != nil {
+		t.Fatal("Expected N-1 to be a valid verifier but have ", err)
+	}
+
+	for _, tt := range []struct {
+		secret []byte
+		err    error
+	}{
+		{secret: make([]byte, size+1), err: ErrInvalidLength},
+		{secret: []byte{1}, err: ErrInvalidSecret},
+		{secret: modulusMinusOne, err: ErrInvalidSecret},
+	} {
+		_, err := NewServerWithSecretWithOptions(group.Modulus(), verifier, tt.secret, 0, WithGroup(group))
+		expectInputError(t, err, "server secret", tt.err)
+	}
+}
+
+func TestValidateClientInputs(t *testing.T) {
+	key, server, serverEphemeral := newTestChallenge(t)
+	size := server.group.bitLength / 8
+
+	for _, tt := range []struct {
+		ephemeral []byte
+		err       error
+	}{
+		{ephemeral: nil, err: ErrInvalidLength},
+		{ephemeral: serverEphemeral[1:], err: ErrInvalidLength},
+		{ephemeral: append(append([]byte{}, serverEphemeral...), 0), err: ErrInvalidLength},
+		{ephemeral: make([]byte, size), err: ErrEphemeralOutOfBounds},
+		{ephemeral: server.group.Modulus(), err: ErrEphemeralOutOfBounds},
+	} {
+		_, err := key.GenerateProofs(tt.ephemeral, 0)
+		expectInputError(t, err, "server ephemeral", tt.err)
+	}
+
+	proofs, err := key.GenerateProofs(serverEphemeral, 0)
+	if err != nil {
+		t.Fatal("Expected no error while generating proofs, have ", err)
+	}
+	err = proofs.VerifyServerProof(proofs.ExpectedServerProof[1:])
+	expectInputError(t, err, "server proof", ErrInvalidLength)
+	if proofs.sharedSession != nil {
+		t.Fatal("Expected the shared session to be wiped")
+	}
+
+	_, err = HashPassword(4, []byte("abc123"), "jakubqa", make([]byte, SaltLength-1), key.Modulus)
+	expectInputError(t, err, "salt", ErrInvalidLength)
+	_, err = NewPasswordKey(3, "jakubqa", []byte("abc123"), "", testModulusClearSign)
+	expectInputError(t, err, "salt", ErrInvalidLength)
+
+	for _, modulus := range [][]byte{nil, make([]byte, size)} {
+		auth := &Auth{Modulus: modulus, ServerEphemeral: serverEphemeral, HashedPassword: key.HashedPassword, Version: 4}
+		if _, err := auth.GenerateProofs(0); err == nil {
+			t.Fatal("Expected an error while generating proofs for an invalid modulus")
+		}
+		if _, err := auth.GenerateVerifier(0); err == nil {
+			t.Fatal("Expected an error while generating a verifier for an invalid modulus")
+		}
+	}
+}
@@ -0,0 +1,106 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+)
+
+// SaltLength is the size in bytes of the random salt of auth versions 3 and 4.
+const SaltLength = 10
+
+// DefaultVersion is the auth version used for new verifiers.
+const DefaultVersion = 4
+
+// VerifierBundle holds everything the server needs to register a password,
+// e.g. on signup or password change.
+type VerifierBundle struct {
+	Version   int    `json:"Version"`
+	ModulusID string `json:"ModulusID"`
+	Salt      []byte `json:"Salt"`
+	Verifier  []byte `json:"Verifier"`
+}
+
+// NewVerifierBundle verifies the signed modulus, generates a salt of the right
+// length and computes the verifier of the password. The auth version defaults
+// to DefaultVersion and can be set with WithVersion. Legacy versions 0 to 2 are
+// not salted and require WithUsername.
+func NewVerifierBundle(password []byte, signedModulus string, opts ...Option) (*VerifierBundle, error) {
+	options := newOptions(opts)
+	version := DefaultVersion
+	if options.version != nil {
+		version = *options.version
+	}
+
+	modulus, err := readClearSignedMessage(signedModulus)
+	if err != nil {
+		return nil, err
+	}
+	modulusData, err := base64.StdEncoding.DecodeString(modulus)
+	if err != nil {
+		return nil, err
+	}
+	bitLength := len(modulusData) * 8
+	group, err := sharedGroup(options.group, modulusData, bitLength)
+	if err != nil {
+		return nil, err
+	}
+
+	var salt []byte
+	switch {
+	case version >= 3:
+		salt = make([]byte, SaltLength)
+		if _, err := io.ReadFull(randReaderOrDefault(options.randReader), salt); err != nil {
+			return nil, err
+		}
+	case options.username == "":
+		return nil, ErrUsernameRequired
+	}
+
+	hashedPassword, err := group.suite.hashPassword(version, password, options.username, salt, modulusData)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(hashedPassword)
+
+	verifier, err := (&Auth{Modulus: modulusData, HashedPassword: hashedPassword, Version: version, group: group}).GenerateVerifier(bitLength)
+	if err != nil {
+		return nil, err
+	}
+
+	return &VerifierBundle{
+		Version:   version,
+		ModulusID: group.ID(),
+		Salt:      salt,
+		Verifier:  verifier,
+	}, nil
+}
+
+// modulusID returns the hex SHA-256 fingerprint of the raw modulus.
+func modulusID(modulusBytes []byte) string {
+	sum := sha256.Sum256(modulusBytes)
+	return hex.EncodeToString(sum[:])
+}
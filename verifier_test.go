@@ -0,0 +1,96 @@
+//  The MIT License
+//
+//  Copyright (c) 2019 Proton Technologies AG
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a copy
+//  of this software and associated documentation files (the "Software"), to deal
+//  in the Software without restriction, including without limitation the rights
+//  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//  copies of the Software, and to permit persons to whom the Software is
+//  furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included in
+//  all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//  THE SOFTWARE.
+
+package srp
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestNewVerifierBundle(t *testing.T) {
+	var password = []byte("abc123")
+
+	bundle, err := NewVerifierBundle(password, testModulusClearSign)
+	if err != nil {
+		t.Fatal("Expected no error while generating verifier bundle, have ", err)
+	}
+	if bundle.Version != DefaultVersion {
+		t.Fatalf("Expected version %d but have %d", DefaultVersion, bundle.Version)
+	}
+	if len(bundle.Salt) != SaltLength {
+		t.Fatalf("Expected a salt of %d bytes but have %d", SaltLength, len(bundle.Salt))
+	}
+	group, err := NewGroupFromSigned(testModulusClearSign, 2048)
+	if err != nil {
+		t.Fatal("Expected no error while creating group, have ", err)
+	}
+	if bundle.ModulusID != group.ID() {
+		t.Fatalf("Expected modulus ID '%s' but have '%s'", group.ID(), bundle.ModulusID)
+	}
+
+	server, err := NewServerWithOptions(group.Modulus(), bundle.Verifier, 2048, WithGroup(group))
+	if err != nil {
+		t.Fatal("Expected no error while creating server, have ", err)
+	}
+	challenge, err := server.GenerateChallenge()
+	if err != nil {
+		t.Fatal("Expected no error while generating challenge, have ", err)
+	}
+	auth, err := NewAuth(
+		bundle.Version,
+		"Test",
+		password,
+		base64.StdEncoding.EncodeToString(bundle.Salt),
+		testModulusClearSign,
+		base64.StdEncoding.EncodeToString(challenge),
+	)
+	if err != nil {
+		t.Fatal("Expected no error while creating auth, have ", err)
+	}
+	proofs, err := auth.GenerateProofs(2048)
+	if err != nil {
+		t.Fatal("Expected no error while generating client proofs, have ", err)
+	}
+	if _, err := server.VerifyProofs(proofs.ClientEphemeral, proofs.ClientProof); err != nil {
+		t.Fatal("Expected no error while verifying client proofs, have ", err)
+	}
+}
+
+func TestNewVerifierBundleVersions(t *testing.T) {
+	if _, err := NewVerifierBundle([]byte("abc123"), testModulusClearSign, WithVersion(2)); !errors.Is(err, ErrUsernameRequired) {
+		t.Fatal("Expected the ErrUsernameRequired but have ", err)
+	}
+
+	bundle, err := NewVerifierBundle([]byte("abc123"), testModulusClearSign, WithVersion(2), WithUsername("jakubqa"))
+	if err != nil {
+		t.Fatal("Expected no error while generating legacy verifier bundle, have ", err)
+	}
+	if bundle.Version != 2 || bundle.Salt != nil {
+		t.Fatal("Expected an unsalted version 2 verifier bundle")
+	}
+
+	if _, err := NewVerifierBundle([]byte("abc123"), testModulusClearSign, WithVersion(5)); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Fatal("Expected the ErrUnsupportedVersion but have ", err)
+	}
+}
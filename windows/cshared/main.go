@@ -27,13 +27,18 @@ import (
 	"bytes"
 	"encoding/binary"
 	srp "github.com/ProtonMail/go-srp" //this could hange to repo link
+	"io"
 	"math/rand"
 )
 
+// randReader is the source of randomness of the exported functions, nil for
+// the default one.
+var randReader io.Reader
+
 /// SetTest only when do the tests
 //export SetTest
 func SetTest() {
-	srp.RandReader = rand.New(rand.NewSource(42))
+	randReader = rand.New(rand.NewSource(42))
 }
 
 /// export interfaces
@@ -52,7 +57,7 @@ func GetModulusKey() string {
 //export GenerateProofs
 func GenerateProofs(version int32, username string, password []byte, salt, signedModulus, serverEphemeral string, bits int32) []byte {
 	v := int(version)
-	auth, err := srp.NewAuth(v, username, password, salt, signedModulus, serverEphemeral)
+	auth, err := srp.NewAuthWithOptions(v, username, password, salt, signedModulus, serverEphemeral, srp.WithRandReader(randReader))
 	clear(password)
 
 	buf := bytes.Buffer{}
@@ -103,7 +108,7 @@ func GenerateProofs(version int32, username string, password []byte, salt, signe
 /// GenerateVerifier return back the raw bytes verifier.
 //export GenerateVerifier
 func GenerateVerifier(password []byte, signedModulus string, rawSalt []byte, bits int32) []byte {
-	auth, err := srp.NewAuthForVerifier(password, signedModulus, rawSalt)
+	auth, err := srp.NewAuthForVerifierWithOptions(password, signedModulus, rawSalt, srp.WithRandReader(randReader))
 	clear(password)
 
 	b := bytes.Buffer{}